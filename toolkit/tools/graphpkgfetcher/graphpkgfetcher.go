@@ -417,6 +417,13 @@ func downloadSingleDeltaRPM(realDependencyGraph *pkggraph.PkgGraph, buildNode *p
 		buildNode.State = pkggraph.StateDelta
 		runNode.State = pkggraph.StateDelta
 
+		// Record the repo the delta RPM was downloaded from, so the build summary can report it. The repo is unknown
+		// if the RPM was already in the cache.
+		if repoID, found := cloner.ClonedPackageRepo(fullyQualifiedRpmName); found {
+			buildNode.SourceRepo = repoID
+			runNode.SourceRepo = repoID
+		}
+
 		// Update the build and run nodes to point to the new RPM in the cache
 		runNode.RpmPath = cachedRPMPath
 		buildNode.RpmPath = cachedRPMPath
@@ -6,17 +6,22 @@ package writerhook
 import (
 	"fmt"
 	"io"
+	"regexp"
 	"sync"
 
 	"github.com/sirupsen/logrus"
 )
 
+// ansiColorRegex matches the ANSI escape sequences used to color terminal output, e.g. "\x1b[31m".
+var ansiColorRegex = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
 // WriterHook is a hook to handle writing to a writer at a custom log level
 type WriterHook struct {
-	lock      sync.Mutex
-	level     logrus.Level
-	writer    io.Writer
-	formatter logrus.Formatter
+	lock        sync.Mutex
+	level       logrus.Level
+	writer      io.Writer
+	formatter   logrus.Formatter
+	stripColors bool
 }
 
 // NewWriterHook returns new WriterHook
@@ -29,6 +34,8 @@ func NewWriterHook(writer io.Writer, level logrus.Level, useColors bool) *Writer
 		level:     level,
 		writer:    writer,
 		formatter: formatter,
+		// Messages may be colored for the terminal, keep the escape sequences out of plain output such as log files.
+		stripColors: !useColors,
 	}
 }
 
@@ -42,6 +49,13 @@ func (h *WriterHook) Fire(entry *logrus.Entry) (err error) {
 	h.lock.Lock()
 	defer h.lock.Unlock()
 
+	// The entry is shared with the other hooks, strip the colors from a copy.
+	if h.stripColors && ansiColorRegex.MatchString(entry.Message) {
+		plainEntry := *entry
+		plainEntry.Message = ansiColorRegex.ReplaceAllString(entry.Message, "")
+		entry = &plainEntry
+	}
+
 	msg, err := h.formatter.Format(entry)
 	if err != nil {
 		return
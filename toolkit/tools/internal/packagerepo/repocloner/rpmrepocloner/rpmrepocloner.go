@@ -101,6 +101,7 @@ type RpmRepoCloner struct {
 	repoIDCache           string
 	reposArgsList         [][]string
 	reposFlags            uint64
+	clonedPackageRepos    map[string]string
 }
 
 // ConstructCloner constructs a new RpmRepoCloner.
@@ -651,6 +652,7 @@ func (r *RpmRepoCloner) clonePackage(baseArgs []string) (preBuilt bool, err erro
 
 		if err == nil {
 			preBuilt = r.reposArgsHaveOnlyLocalSources(reposArgs)
+			r.recordClonedPackageRepos(splitStdout)
 			break
 		}
 	}
@@ -658,6 +660,70 @@ func (r *RpmRepoCloner) clonePackage(baseArgs []string) (preBuilt bool, err erro
 	return
 }
 
+// ClonedPackageRepo returns the ID of the repo a package was downloaded from by this cloner.
+// The package is identified by its "<name>-<version>-<release>.<arch>" name, as passed to CloneRawPackageNames.
+// Returns false if the package was not downloaded by this cloner, e.g. because it was already in the clone directory.
+func (r *RpmRepoCloner) ClonedPackageRepo(rawPackageName string) (repoID string, found bool) {
+	repoID, found = r.clonedPackageRepos[rawPackageName]
+	return
+}
+
+// recordClonedPackageRepos remembers the repo of each package tdnf listed as installed, so ClonedPackageRepo can report it.
+func (r *RpmRepoCloner) recordClonedPackageRepos(tdnfStdoutLines []string) {
+	if r.clonedPackageRepos == nil {
+		r.clonedPackageRepos = make(map[string]string)
+	}
+
+	for packageName, repoID := range parseInstalledPackageRepos(tdnfStdoutLines) {
+		r.clonedPackageRepos[packageName] = repoID
+	}
+}
+
+// parseInstalledPackageRepos reads the list of packages tdnf prints after an "Installing:" line, which ends with an
+// empty line, and maps the "<name>-<version>-<release>.<arch>" name of each package to the ID of its repo.
+// Each package is listed as: <name> <arch> [<epoch>:]<version>-<release> <repo_id> <sizes...>
+func parseInstalledPackageRepos(tdnfStdoutLines []string) (packageRepos map[string]string) {
+	const (
+		packageListPrefix = "Installing:"
+		epochDelimiter    = ":"
+	)
+
+	const (
+		nameIndex     = iota
+		archIndex     = iota
+		versionIndex  = iota
+		repoIDIndex   = iota
+		minFieldCount = iota
+	)
+
+	packageRepos = make(map[string]string)
+	inPackageList := false
+	for _, line := range tdnfStdoutLines {
+		if !inPackageList {
+			inPackageList = strings.HasPrefix(strings.TrimSpace(line), packageListPrefix)
+			continue
+		} else if strings.TrimSpace(line) == "" {
+			break
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < minFieldCount {
+			logger.Log.Debugf("Unexpected TDNF package list entry: %s", line)
+			continue
+		}
+
+		version := fields[versionIndex]
+		if epochEnd := strings.Index(version, epochDelimiter); epochEnd >= 0 {
+			version = version[epochEnd+len(epochDelimiter):]
+		}
+
+		packageName := fmt.Sprintf("%s-%s.%s", fields[nameIndex], version, fields[archIndex])
+		packageRepos[packageName] = fields[repoIDIndex]
+	}
+
+	return
+}
+
 func convertPackageVersionToTdnfArg(pkgVer *pkgjson.PackageVer) (tdnfArg string) {
 	tdnfArg = pkgVer.Name
 
@@ -0,0 +1,40 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package rpmrepocloner
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	logger.InitStderrLog()
+	os.Exit(m.Run())
+}
+
+func TestParseInstalledPackageRepos(t *testing.T) {
+	stdout := `Refreshing metadata for: 'CBL-Mariner Official Base 2.0 x86_64'
+
+Installing:
+zlib                    x86_64      1.2.13-1.cm2          mariner-official-base   105.04k    49.79k
+perl-libs               x86_64      4:5.34.1-489.cm2      mariner-official-base     9.32M     2.02M
+
+Total installed size:   9.42M
+Total download size:    2.07M
+`
+
+	packageRepos := parseInstalledPackageRepos(strings.Split(stdout, "\n"))
+	assert.Equal(t, map[string]string{
+		"zlib-1.2.13-1.cm2.x86_64":        "mariner-official-base",
+		"perl-libs-5.34.1-489.cm2.x86_64": "mariner-official-base",
+	}, packageRepos)
+}
+
+func TestParseInstalledPackageReposWithoutPackageList(t *testing.T) {
+	assert.Empty(t, parseInstalledPackageRepos(strings.Split("No package zlib-9.9 available\n", "\n")))
+}
@@ -10,6 +10,9 @@ import (
 	"os/exec"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/logger"
 
@@ -136,7 +139,29 @@ func ExecuteLive(squashErrors bool, program string, args ...string) (err error)
 // If printOutputOnError is true, the full output of the command will be printed after completion if the command returns an error. In the event
 // the buffer becomes full the oldest buffered output is discarded.
 func ExecuteLiveWithCallback(onStdout, onStderr func(...interface{}), printOutputOnError bool, program string, args ...string) (err error) {
-	var outputChan chan string
+	_, err = ExecuteLiveWithCallbackAndUsage(onStdout, onStderr, printOutputOnError, program, args...)
+	return
+}
+
+// ExecuteLiveWithCallbackAndUsage behaves like ExecuteLiveWithCallback, and also returns the resource usage of the command
+// once it exits. The usage includes any descendant processes the command waited for.
+// usage is nil if the command could not be started.
+func ExecuteLiveWithCallbackAndUsage(onStdout, onStderr func(...interface{}), printOutputOnError bool, program string, args ...string) (usage *syscall.Rusage, err error) {
+	const noTimeout = 0
+
+	usage, _, err = ExecuteLiveWithCallbackAndTimeout(noTimeout, onStdout, onStderr, printOutputOnError, program, args...)
+	return
+}
+
+// ExecuteLiveWithCallbackAndTimeout behaves like ExecuteLiveWithCallbackAndUsage, and also stops the command's process group
+// with a SIGINT if it is still running after timeout. timedOut reports if the command failed after being stopped this way.
+// A timeout of zero or less lets the command run until it exits.
+func ExecuteLiveWithCallbackAndTimeout(timeout time.Duration, onStdout, onStderr func(...interface{}), printOutputOnError bool, program string, args ...string) (usage *syscall.Rusage, timedOut bool, err error) {
+	var (
+		outputChan    chan string
+		deadlineTimer *time.Timer
+		deadlineHit   atomic.Bool
+	)
 	const outputChanBufferSize = 1500
 
 	cmd := exec.Command(program, args...)
@@ -162,6 +187,19 @@ func ExecuteLiveWithCallback(onStdout, onStderr func(...interface{}), printOutpu
 
 	defer untrackProcess(cmd)
 
+	if timeout > 0 {
+		deadlineTimer = time.AfterFunc(timeout, func() {
+			deadlineHit.Store(true)
+			logger.Log.Warnf("Stopping (%s) after exceeding its timeout of %s", cmd.Path, timeout)
+
+			// Signal the whole process group so the command's children are stopped as well.
+			killErr := unix.Kill(-cmd.Process.Pid, unix.SIGINT)
+			if killErr != nil {
+				logger.Log.Errorf("Unable to stop (%s): %v", strings.Join(cmd.Args, " "), killErr)
+			}
+		})
+	}
+
 	wg := new(sync.WaitGroup)
 	wg.Add(2)
 
@@ -174,6 +212,15 @@ func ExecuteLiveWithCallback(onStdout, onStderr func(...interface{}), printOutpu
 	wg.Wait()
 	err = cmd.Wait()
 
+	if deadlineTimer != nil {
+		deadlineTimer.Stop()
+	}
+	timedOut = err != nil && deadlineHit.Load()
+
+	if cmd.ProcessState != nil {
+		usage, _ = cmd.ProcessState.SysUsage().(*syscall.Rusage)
+	}
+
 	// Optionally dump the output in the event of an error
 	if outputChan != nil {
 		close(outputChan)
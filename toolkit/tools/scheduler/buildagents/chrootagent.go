@@ -31,12 +31,16 @@ func (c *ChrootAgent) Initialize(config *BuildAgentConfig) (err error) {
 	return
 }
 
-// BuildPackage builds a given file and returns the output files or error.
+// BuildPackage builds a given file and returns the output files or error, along with the peak resident memory
+// of the build in bytes, or zero if it is unknown.
 // - inputFile is the SRPM to build.
 // - logName is the file name to save the package build log to.
 // - outArch is the target architecture to build for.
 // - dependencies is a list of dependencies that need to be installed before building.
-func (c *ChrootAgent) BuildPackage(inputFile, logName, outArch string, dependencies []string) (builtFiles []string, logFile string, err error) {
+func (c *ChrootAgent) BuildPackage(inputFile, logName, outArch string, dependencies []string) (builtFiles []string, logFile string, peakRSS int64, err error) {
+	// ru_maxrss is reported in kilobytes.
+	const maxRSSUnit = 1024
+
 	// On success, pkgworker will print a comma-seperated list of all RPMs built to stdout.
 	// This will be the last stdout line written.
 	const delimiter = ","
@@ -54,7 +58,14 @@ func (c *ChrootAgent) BuildPackage(inputFile, logName, outArch string, dependenc
 	}
 
 	args := serializeChrootBuildAgentConfig(c.config, inputFile, logFile, outArch, dependencies)
-	err = shell.ExecuteLiveWithCallback(onStdout, logger.Log.Trace, true, c.config.Program, args...)
+	usage, timedOut, err := shell.ExecuteLiveWithCallbackAndTimeout(c.config.Timeout, onStdout, logger.Log.Trace, true, c.config.Program, args...)
+	if usage != nil {
+		peakRSS = usage.Maxrss * maxRSSUnit
+	}
+
+	if timedOut {
+		err = fmt.Errorf("%w (%s): %v", ErrBuildTimedOut, c.config.Timeout, err)
+	}
 
 	if err == nil && lastStdoutLine != "" {
 		builtFiles = strings.Split(lastStdoutLine, delimiter)
@@ -0,0 +1,52 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package buildagents
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	logger.InitStderrLog()
+	os.Exit(m.Run())
+}
+
+// writeTestWorker writes a stand-in for pkgworker which runs script, ignoring the arguments it is given.
+func writeTestWorker(t *testing.T, script string) string {
+	workerPath := filepath.Join(t.TempDir(), "pkgworker")
+	assert.NoError(t, os.WriteFile(workerPath, []byte("#!/bin/sh\n"+script+"\n"), 0755))
+	return workerPath
+}
+
+func TestChrootAgentStopsBuildAfterTimeout(t *testing.T) {
+	agent := NewChrootAgent()
+	assert.NoError(t, agent.Initialize(&BuildAgentConfig{Program: writeTestWorker(t, "exec sleep 30"), LogDir: t.TempDir(), Timeout: 100 * time.Millisecond}))
+
+	start := time.Now()
+	builtFiles, _, _, err := agent.BuildPackage("pkg-1.0-1.cm2.src.rpm", "pkg-1.0-1.cm2.src.rpm.log", "x86_64", nil)
+	assert.True(t, errors.Is(err, ErrBuildTimedOut))
+	assert.Empty(t, builtFiles)
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
+
+func TestChrootAgentBuildWithinTimeout(t *testing.T) {
+	agent := NewChrootAgent()
+	assert.NoError(t, agent.Initialize(&BuildAgentConfig{Program: writeTestWorker(t, "echo /out/pkg-1.0-1.cm2.x86_64.rpm"), LogDir: t.TempDir(), Timeout: time.Minute}))
+
+	builtFiles, _, _, err := agent.BuildPackage("pkg-1.0-1.cm2.src.rpm", "pkg-1.0-1.cm2.src.rpm.log", "x86_64", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/out/pkg-1.0-1.cm2.x86_64.rpm"}, builtFiles)
+
+	assert.NoError(t, agent.Initialize(&BuildAgentConfig{Program: writeTestWorker(t, "exit 1"), LogDir: t.TempDir(), Timeout: time.Minute}))
+	_, _, _, err = agent.BuildPackage("pkg-1.0-1.cm2.src.rpm", "pkg-1.0-1.cm2.src.rpm.log", "x86_64", nil)
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrBuildTimedOut))
+}
@@ -3,7 +3,14 @@
 
 package buildagents
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrBuildTimedOut is wrapped by the error BuildPackage returns when a build was stopped for exceeding BuildAgentConfig.Timeout.
+var ErrBuildTimedOut = errors.New("build exceeded its timeout")
 
 // BuildAgentConfig represents configuration options a BuildAgent would need to successfully build a given package.
 type BuildAgentConfig struct {
@@ -27,6 +34,7 @@ type BuildAgentConfig struct {
 	RunCheck  bool
 	UseCcache bool
 	MaxCpu    string
+	Timeout   time.Duration // Stops a package build still running after this long, zero disables the deadline
 
 	LogDir   string
 	LogLevel string
@@ -37,12 +45,13 @@ type BuildAgent interface {
 	// Initialize initializes the build agent with the given configuration.
 	Initialize(config *BuildAgentConfig) error
 
-	// BuildPackage builds a given file and returns the output files or error.
+	// BuildPackage builds a given file and returns the output files or error, along with the peak resident memory
+	// of the build in bytes, or zero if it is unknown.
 	// - inputFile is the SRPM to build.
 	// - logName is the file name to save the package build log to.
 	// - outArch is the machine architecture where the output binary will run
 	// - dependencies is a list of dependencies that need to be installed before building.
-	BuildPackage(inputFile, logName, outArch string, dependencies []string) ([]string, string, error)
+	BuildPackage(inputFile, logName, outArch string, dependencies []string) ([]string, string, int64, error)
 
 	// Config returns a copy of the agent's configuration.
 	Config() BuildAgentConfig
@@ -9,11 +9,14 @@ import (
 	"os/signal"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/exe"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/jsonutils"
 	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/logger"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/packagerepo/repocloner/rpmrepocloner"
 	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
 	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkgjson"
 	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/shell"
@@ -32,6 +35,13 @@ const (
 	defaultCheckAttempts = "1"
 )
 
+// Process exit codes reported by the scheduler when the build does not succeed.
+const (
+	exitCodeGraphError         = 1 // The graph could not be processed, no more specific status is available
+	exitCodePackageFailures    = 2 // One or more packages failed to build or were blocked, or dependencies are unresolved in strict mode
+	exitCodeToolchainConflicts = 3 // Toolchain packages were rebuilt while not allowed
+)
+
 // schedulerChannels represents the communication channels used by a build agent.
 // Unlike BuildChannels, schedulerChannels holds bidirectional channels that
 // only the top-level scheduler should have. BuildChannels contains directional channels.
@@ -49,7 +59,44 @@ var (
 	inputGraphFile  = exe.InputFlag(app, "Path to the DOT graph file to build.")
 	outputGraphFile = exe.OutputFlag(app, "Path to save the built DOT graph file.")
 
-	outputCSVFile    = app.Flag("output-build-state-csv-file", "Path to save the CSV file.").Required().String()
+	outputCSVFile    = app.Flag("output-build-state-csv-file", "Path to save the CSV file. Use '-' to write it to stdout, which the other summary file flags also support.").Required().String()
+	outputJSONFile   = app.Flag("output-build-state-json-file", "Optional path to save the build summary as a JSON file.").String()
+	summaryURL       = app.Flag("post-build-summary-url", "Optional URL to POST the JSON build summary to once the build is done, e.g. a build dashboard. Failing to post it doesn't fail the build.").String()
+	outputYAMLFile   = app.Flag("output-build-state-yaml-file", "Optional path to save the build summary as a YAML file.").String()
+	outputSQLiteFile = app.Flag("output-build-state-sqlite-file", "Optional path of a SQLite database to add the state of every package to, in its builds table. Rows are tagged with the --output-build-state-csv-build-id value if set, or the build's start time otherwise. Requires sqlite3.").String()
+	outputStateFile  = app.Flag("output-build-state-file", "Optional path to save the recorded build results as a JSON file, so the build summary can be regenerated offline along with the built graph file.").String()
+	csvDurations     = app.Flag("output-build-state-csv-durations", "Include the build duration of each SRPM in the CSV file.").Bool()
+	csvKnownIssues   = app.Flag("output-build-state-csv-known-issues", "Optional path to a JSON object mapping error signature regular expressions to known issue ticket IDs. Failed SRPMs matching a signature are annotated with its ticket in the Known Issue column of the CSV file.").ExistingFile()
+	csvPerStateDir   = app.Flag("output-build-state-csv-dir", "Directory to save one CSV file per build state to (e.g. built.csv, failed.csv, blocked.csv). The files have the same columns as the CSV file, without the State column.").String()
+	csvBuildID       = app.Flag("output-build-state-csv-build-id", "Write a metadata header with this build ID, the build's start time and the toolkit version as '#' comment lines at the top of the CSV file.").String()
+	csvAnonymize     = app.Flag("output-build-state-csv-anonymize", "Replace the package names in the CSV file with hashes, so it can be shared without disclosing the package set. The same name always maps to the same hash.").Bool()
+	csvAppend        = app.Flag("output-build-state-csv-append", "Merge the CSV file with an existing one with the same columns instead of overwriting it. Packages found in both keep the state from this build.").Bool()
+	csvColumns       = app.Flag("output-build-state-csv-columns", fmt.Sprintf("Comma separated list of columns to write to the CSV file, in order. Valid columns: %s. Omit this argument to write the default columns.", strings.Join(schedulerutils.SummaryColumns, ", "))).String()
+	csvUpdateRate    = app.Flag("output-build-state-csv-update-interval", "Periodically overwrite the CSV file with the state of the running build, no more often than this interval (e.g. '30s'). If set to 0, the CSV file is only written once the build is done.").Default("0s").Duration()
+	outputJUnitFile  = app.Flag("output-build-state-junit-file", "Optional path to save the build summary as a JUnit XML file.").String()
+	outputMarkdown   = app.Flag("output-build-state-markdown-file", "Optional path to save the build summary as a markdown file.").String()
+	outputHTMLFile   = app.Flag("output-build-state-html-file", "Optional path to save the build summary as an HTML file.").String()
+	outputPromFile   = app.Flag("output-build-state-prometheus-file", "Optional path to save the build counts as Prometheus metrics, e.g. in to the node_exporter textfile collector directory. The file name must end with '.prom' to be picked up by the collector.").String()
+	outputConflicts  = app.Flag("output-conflicts-csv-file", "Optional path to save the rebuilt toolchain RPMs and SRPMs as a CSV file, with the versions they were rebuilt at. Written even if toolchain rebuilds are allowed.").String()
+	failureGraph     = app.Flag("output-failure-graph-file", "Optional path to save the subgraph of failed and blocked SRPMs as a DOT file.").String()
+	failuresDigest   = app.Flag("output-failures-digest-file", "Optional path to save a digest of the failed and blocked SRPMs for triage. Not written if nothing failed.").String()
+	regressionBase   = app.Flag("fail-on-regression-from", "Optional path to a baseline CSV file written by a previous build. Fail the build if any package built in the baseline failed in this build.").String()
+	topFailures      = app.Flag("summary-top-failures", "Maximum number of failed SRPMs to list in the build summary. If set to 0, all failures are listed.").Default("0").Int()
+	summarySizes     = app.Flag("summary-output-sizes", "Report the total size of the built RPMs and the largest ones in the build summary.").Bool()
+	summaryBudget    = app.Flag("summary-expected-duration", "Warn in the build summary if the build took longer than this duration (e.g. '6h'), listing the builds which contributed most. If set to 0, no warning is printed.").Default("0s").Duration()
+	summaryTimeline  = app.Flag("summary-timeline", "Log when each built SRPM was queued, started and finished after the build summary, sorted by start time. Verbose, meant for debugging scheduling gaps.").Bool()
+	summaryLevel     = app.Flag("summary-verbosity", "How much of the build summary to print: 0 prints the counts only, 1 also lists the failed, blocked and unresolved SRPMs, 2 prints everything. Toolchain conflicts are always printed.").Default("2").Int()
+	prebuiltRepos    = app.Flag("summary-prebuilt-repo-file", "Path to a repo file to query for prebuilt versions of the failed SRPMs, which are flagged in the build summary. May be given multiple times.").ExistingFiles()
+	excludeToolchain = app.Flag("summary-exclude-toolchain", "Leave the SRPMs producing toolchain RPMs out of the build summary's counts and listings, to focus on the other packages. Toolchain conflicts are still reported.").Bool()
+	resultsSocket    = app.Flag("results-socket", "Path of a Unix domain socket to publish each build result to as a JSON line, so the build can be monitored live.").String()
+	resultsStream    = app.Flag("output-build-results-ndjson-file", "Optional path to write each build result to as a newline-delimited JSON object, as the builds complete. The file is overwritten.").String()
+	strictUnresolved = app.Flag("strict-unresolved", "Treat unresolved dependencies as build failures: log them as errors and exit with a non-zero status.").Bool()
+	quietResults     = app.Flag("quiet-build-results", "Don't log each successfully built or prebuilt SRPM. Failures, warnings and the build summary are still logged.").Bool()
+	printCounts      = app.Flag("print-build-counts", "Print the build counts to stdout as a single line of key=value pairs once the build is done.").Bool()
+	maxSRPMNodes     = app.Flag("summary-max-nodes-per-srpm", "List the SRPMs with more build and run nodes in the graph than this in the build summary, as their specs may generate too many subpackages. If set to 0, no SRPMs are listed.").Default("0").Int()
+	fullSRPMPaths    = app.Flag("summary-full-srpm-paths", "List and record the SRPMs in the build summary and CSV files by their full path instead of their base name. Useful when the same SRPM is built from multiple source trees.").Bool()
+	deliverables     = app.Flag("summary-deliverables", "Space separated list of SRPM base names (glob patterns allowed) of standalone deliverables, which are not reported as potentially unused in the build summary when no other SRPM depends on them.").String()
+	summaryPackages  = app.Flag("summary-packages", "Space separated list of SRPM base names (glob patterns allowed) to restrict the build summary to. Omit this argument to summarize all SRPMs.").String()
 	workDir          = app.Flag("work-dir", "The directory to create the build folder").Required().String()
 	workerTar        = app.Flag("worker-tar", "Full path to worker_chroot.tar.gz").Required().ExistingFile()
 	repoFile         = app.Flag("repo-file", "Full path to local.repo").Required().ExistingFile()
@@ -78,12 +125,15 @@ var (
 	useCcache                  = app.Flag("use-ccache", "Automatically install and use ccache during package builds").Bool()
 	allowToolchainRebuilds     = app.Flag("allow-toolchain-rebuilds", "Allow toolchain packages to rebuild without causing an error.").Bool()
 	maxCPU                     = app.Flag("max-cpu", "Max number of CPUs used for package building").Default("").String()
+	buildTimeout               = app.Flag("build-timeout", "Stop a package build still running after this duration (e.g. '2h') and report it as timed out. If set to 0, builds have no deadline.").Default("0s").Duration()
 
 	validBuildAgentFlags = []string{buildagents.TestAgentFlag, buildagents.ChrootAgentFlag}
 	buildAgent           = app.Flag("build-agent", "Type of build agent to build packages with.").PlaceHolder(exe.PlaceHolderize(validBuildAgentFlags)).Required().Enum(validBuildAgentFlags...)
 	buildAgentProgram    = app.Flag("build-agent-program", "Path to the build agent that will be invoked to build packages.").String()
 	workers              = app.Flag("workers", "Number of concurrent build agents to spawn. If set to 0, will automatically set to the logical CPU count.").Default(defaultWorkerCount).Int()
 
+	dryRun = app.Flag("dry-run", "Print how many SRPMs would be built, restored from the cache or are already satisfied, then exit without building anything.").Bool()
+
 	pkgsToIgnore = app.Flag("ignored-packages", "Space separated list of specs ignoring rebuilds if their dependencies have been updated. Will still build if all of the spec's RPMs have not been built.").String()
 
 	pkgsToBuild   = app.Flag("packages", "Space separated list of top-level packages that should be built. Omit this argument to build all packages.").String()
@@ -115,6 +165,10 @@ func main() {
 		logger.Log.Fatalf("Value in --build-attempts must be greater than zero. Found %d.", *buildAttempts)
 	}
 
+	if _, err := schedulerutils.SummaryVerbosityFromLevel(*summaryLevel); err != nil {
+		logger.Log.Fatalf("Invalid value in --summary-verbosity: %s.", err)
+	}
+
 	dependencyGraph, err := pkggraph.ReadDOTGraphFile(*inputGraphFile)
 	if err != nil {
 		logger.Log.Fatalf("Failed to read DOT graph with error:\n%s", err)
@@ -151,6 +205,7 @@ func main() {
 		RunCheck:  *runCheck,
 		UseCcache: *useCcache,
 		MaxCpu:    *maxCPU,
+		Timeout:   *buildTimeout,
 
 		LogDir:   *buildLogsDir,
 		LogLevel: *logLevel,
@@ -174,9 +229,43 @@ func main() {
 	signal.Notify(signals, unix.SIGINT, unix.SIGTERM)
 	go cancelBuildsOnSignal(signals, agent)
 
-	err = buildGraph(*inputGraphFile, *outputGraphFile, agent, *workers, *buildAttempts, *checkAttempts, *stopOnFailure, !*noCache, finalPackagesToBuild, packagesToRebuild, packagesToIgnore, toolchainPackages, *optimizeWithCachedImplicit, *allowToolchainRebuilds)
+	if *resultsSocket != "" {
+		publisher, publisherErr := schedulerutils.NewResultSocketPublisher(*resultsSocket)
+		if publisherErr != nil {
+			logger.Log.Fatalf("Unable to listen on results socket '%s', error: %s.", *resultsSocket, publisherErr)
+		}
+		defer publisher.Close()
+		schedulerutils.RegisterResultObserver(publisher)
+	}
+
+	if *resultsStream != "" {
+		streamWriter, streamErr := schedulerutils.NewResultStreamWriter(*resultsStream)
+		if streamErr != nil {
+			logger.Log.Fatalf("Unable to create results stream file '%s', error: %s.", *resultsStream, streamErr)
+		}
+		defer streamWriter.Close()
+		schedulerutils.RegisterResultObserver(streamWriter)
+	}
+
+	status, err := buildGraph(*inputGraphFile, *outputGraphFile, agent, *workers, *buildAttempts, *checkAttempts, *stopOnFailure, !*noCache, finalPackagesToBuild, packagesToRebuild, packagesToIgnore, toolchainPackages, *optimizeWithCachedImplicit, *allowToolchainRebuilds, *dryRun)
 	if err != nil {
-		logger.Log.Fatalf("Unable to build package graph.\nFor details see the build summary section above.\nError: %s.", err)
+		logger.Log.Errorf("Unable to build package graph.\nFor details see the build summary section above.\nError: %s.", err)
+		os.Exit(exitCodeFromStatus(status))
+	}
+}
+
+// exitCodeFromStatus selects the process exit code for a failed build based on its status.
+// - status may be nil if the build did not get far enough to calculate it.
+func exitCodeFromStatus(status *schedulerutils.BuildStatus) int {
+	switch {
+	case status == nil:
+		return exitCodeGraphError
+	case status.HasFailures || status.BlockedCount > 0 || status.HasFatalUnresolved:
+		return exitCodePackageFailures
+	case status.HasFatalConflicts:
+		return exitCodeToolchainConflicts
+	default:
+		return exitCodeGraphError
 	}
 }
 
@@ -202,7 +291,8 @@ func cancelBuildsOnSignal(signals chan os.Signal, agent buildagents.BuildAgent)
 
 // buildGraph builds all packages in the dependency graph requested.
 // It will save the resulting graph to outputFile.
-func buildGraph(inputFile, outputFile string, agent buildagents.BuildAgent, workers, buildAttempts int, checkAttempts int, stopOnFailure, canUseCache bool, packagesToBuild, packagesToRebuild, ignoredPackages []*pkgjson.PackageVer, toolchainPackages []string, optimizeWithCachedImplicit bool, allowToolchainRebuilds bool) (err error) {
+// - dryRun only prints the build plan, nothing is built and outputFile is not written.
+func buildGraph(inputFile, outputFile string, agent buildagents.BuildAgent, workers, buildAttempts int, checkAttempts int, stopOnFailure, canUseCache bool, packagesToBuild, packagesToRebuild, ignoredPackages []*pkgjson.PackageVer, toolchainPackages []string, optimizeWithCachedImplicit bool, allowToolchainRebuilds bool, dryRun bool) (status *schedulerutils.BuildStatus, err error) {
 	// graphMutex guards pkgGraph from concurrent reads and writes during build.
 	var graphMutex sync.RWMutex
 
@@ -215,6 +305,11 @@ func buildGraph(inputFile, outputFile string, agent buildagents.BuildAgent, work
 		return
 	}
 
+	if dryRun {
+		schedulerutils.PreviewBuildPlan(pkgGraph, &graphMutex, schedulerutils.NewGraphBuildState(toolchainPackages), packagesToRebuild, canUseCache)
+		return
+	}
+
 	// Setup and start the worker pool and scheduler routine.
 	numberOfNodes := pkgGraph.Nodes().Len()
 
@@ -222,7 +317,7 @@ func buildGraph(inputFile, outputFile string, agent buildagents.BuildAgent, work
 	logger.Log.Infof("Building %d nodes with %d workers", numberOfNodes, workers)
 
 	// After this call pkgGraph will be given to multiple routines and accessing it requires acquiring the mutex.
-	builtGraph, err := buildAllNodes(stopOnFailure, canUseCache, packagesToRebuild, pkgGraph, &graphMutex, goalNode, channels, toolchainPackages, allowToolchainRebuilds)
+	builtGraph, status, err := buildAllNodes(stopOnFailure, canUseCache, packagesToRebuild, pkgGraph, &graphMutex, goalNode, channels, toolchainPackages, allowToolchainRebuilds)
 
 	if builtGraph != nil {
 		graphMutex.RLock()
@@ -275,7 +370,7 @@ func startWorkerPool(agent buildagents.BuildAgent, workers, buildAttempts, check
 // - Attempts to satisfy any unresolved dynamic dependencies with new implicit provides from the build result.
 // - Attempts to subgraph the graph to only contain the requested packages if possible.
 // - Repeat.
-func buildAllNodes(stopOnFailure, canUseCache bool, packagesToRebuild []*pkgjson.PackageVer, pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, goalNode *pkggraph.PkgNode, channels *schedulerChannels, reservedFiles []string, allowToolchainRebuilds bool) (builtGraph *pkggraph.PkgGraph, err error) {
+func buildAllNodes(stopOnFailure, canUseCache bool, packagesToRebuild []*pkgjson.PackageVer, pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, goalNode *pkggraph.PkgNode, channels *schedulerChannels, reservedFiles []string, allowToolchainRebuilds bool) (builtGraph *pkggraph.PkgGraph, status *schedulerutils.BuildStatus, err error) {
 	var (
 		// stopBuilding tracks if the build has entered a failed state and this routine should stop as soon as possible.
 		stopBuilding bool
@@ -292,10 +387,24 @@ func buildAllNodes(stopOnFailure, canUseCache bool, packagesToRebuild []*pkgjson
 		isGraphOptimized  bool
 	)
 
+	buildStartTime := time.Now()
+
 	// Start the build at the leaf nodes.
 	// The build will bubble up through the graph as it processes nodes.
 	buildState := schedulerutils.NewGraphBuildState(reservedFiles)
+
+	// With --stop-on-failure, a fatal toolchain conflict stops the build as soon as it is found instead of at the summary.
+	var conflictErr error
+	if stopOnFailure && !allowToolchainRebuilds {
+		buildState.SetConflictCallback(func(rpm string, builtBy *pkggraph.PkgNode) {
+			logger.Log.Errorf("'%s' rebuilt the toolchain RPM '%s'", builtBy.SRPMFileName(), rpm)
+			if conflictErr == nil {
+				conflictErr = fmt.Errorf("toolchain RPM '%s' was rebuilt by '%s'", rpm, builtBy.SRPMFileName())
+			}
+		})
+	}
 	nodesToBuild := schedulerutils.LeafNodes(pkgGraph, graphMutex, goalNode, buildState, useCachedImplicit)
+	lastSummaryUpdate := buildStartTime
 
 	for {
 		logger.Log.Debugf("Found %d unblocked nodes", len(nodesToBuild))
@@ -346,8 +455,18 @@ func buildAllNodes(stopOnFailure, canUseCache bool, packagesToRebuild []*pkgjson
 		// Process the the next build result
 		res := <-channels.Results
 
-		schedulerutils.PrintBuildResult(res)
-		buildState.RecordBuildResult(res, allowToolchainRebuilds)
+		schedulerutils.PrintBuildResult(res, *quietResults)
+		buildState.RecordBuildResult(res)
+
+		if *csvUpdateRate > 0 && time.Since(lastSummaryUpdate) >= *csvUpdateRate {
+			schedulerutils.UpdateBuildSummary(pkgGraph, graphMutex, buildState, *outputCSVFile)
+			lastSummaryUpdate = time.Now()
+		}
+
+		if !stopBuilding && conflictErr != nil {
+			stopBuilding = true
+			err = conflictErr
+		}
 
 		if !stopBuilding {
 			if res.Err == nil {
@@ -401,6 +520,7 @@ func buildAllNodes(stopOnFailure, canUseCache bool, packagesToRebuild []*pkgjson
 			err = fmt.Errorf("fatal error building package graph:\n%w", err)
 			// Save out the current graph state for debugging
 			builtGraph = pkgGraph
+			status = schedulerutils.CalculateBuildStatus(builtGraph, graphMutex, buildState, allowToolchainRebuilds, *strictUnresolved)
 			return
 		}
 
@@ -435,14 +555,164 @@ func buildAllNodes(stopOnFailure, canUseCache bool, packagesToRebuild []*pkgjson
 	time.Sleep(time.Second)
 
 	builtGraph = pkgGraph
-	schedulerutils.PrintBuildSummary(builtGraph, graphMutex, buildState, allowToolchainRebuilds)
-	schedulerutils.RecordBuildSummary(builtGraph, graphMutex, buildState, *outputCSVFile)
-	if !allowToolchainRebuilds && (len(buildState.ConflictingRPMs()) > 0 || len(buildState.ConflictingSRPMs()) > 0) {
+	// The verbosity level was validated at startup.
+	summaryVerbosity, _ := schedulerutils.SummaryVerbosityFromLevel(*summaryLevel)
+	var prebuiltAlternatives map[string][]string
+	if len(*prebuiltRepos) != 0 && len(buildState.BuildFailures()) != 0 {
+		prebuiltAlternatives = findPrebuiltAlternatives(pkgGraph, graphMutex, buildState, *prebuiltRepos)
+	}
+	summaryOptions := schedulerutils.SummaryOptions{
+		MaxFailuresListed:    *topFailures,
+		BuildStartTime:       buildStartTime,
+		PackageFilter:        exe.ParseListArgument(*summaryPackages),
+		IncludeOutputSizes:   *summarySizes,
+		StrictUnresolved:     *strictUnresolved,
+		ExpectedDuration:     *summaryBudget,
+		ExcludeToolchain:     *excludeToolchain,
+		Verbosity:            summaryVerbosity,
+		PrebuiltAlternatives: prebuiltAlternatives,
+		Deliverables:         exe.ParseListArgument(*deliverables),
+		MaxNodesPerSRPM:      *maxSRPMNodes,
+		FullSRPMPaths:        *fullSRPMPaths,
+	}
+	schedulerutils.PrintBuildSummary(builtGraph, graphMutex, buildState, allowToolchainRebuilds, summaryOptions)
+	if *summaryTimeline {
+		schedulerutils.PrintBuildTimeline(builtGraph, graphMutex, buildState)
+	}
+
+	csvOptions := schedulerutils.SummaryCSVOptions{
+		Columns:          summaryCSVColumns(),
+		AppendToExisting: *csvAppend,
+		FullSRPMPaths:    *fullSRPMPaths,
+		Metadata:         summaryCSVMetadata(buildStartTime),
+	}
+	if *csvAnonymize {
+		csvOptions.PackageLabel = schedulerutils.AnonymizePackageName
+	}
+	if *csvKnownIssues != "" {
+		if knownIssuesErr := jsonutils.ReadJSONFile(*csvKnownIssues, &csvOptions.KnownIssues); knownIssuesErr != nil {
+			logger.Log.Warnf("Failed to read the known issues file '%s', failures won't be annotated. Error: %s", *csvKnownIssues, knownIssuesErr)
+		}
+	}
+	schedulerutils.RecordBuildSummary(builtGraph, graphMutex, buildState, *outputCSVFile, csvOptions)
+	if *csvPerStateDir != "" {
+		schedulerutils.RecordBuildSummaryPerState(builtGraph, graphMutex, buildState, *csvPerStateDir, summaryCSVColumns(), *fullSRPMPaths, summaryCSVMetadata(buildStartTime))
+	}
+	if *outputJSONFile != "" {
+		schedulerutils.RecordBuildSummaryJSON(builtGraph, graphMutex, buildState, *outputJSONFile)
+	}
+	if *summaryURL != "" {
+		schedulerutils.PostBuildSummary(builtGraph, graphMutex, buildState, *summaryURL)
+	}
+	if *outputStateFile != "" {
+		saveErr := schedulerutils.SaveGraphBuildState(buildState, *outputStateFile)
+		if saveErr != nil {
+			logger.Log.Warnf("Failed to write build state file '%s'. Error: %s", *outputStateFile, saveErr)
+		}
+	}
+	if *outputYAMLFile != "" {
+		schedulerutils.RecordBuildSummaryYAML(builtGraph, graphMutex, buildState, *outputYAMLFile)
+	}
+	if *outputSQLiteFile != "" {
+		runID := *csvBuildID
+		if runID == "" {
+			runID = buildStartTime.UTC().Format(time.RFC3339)
+		}
+		schedulerutils.RecordBuildSummarySQLite(builtGraph, graphMutex, buildState, *outputSQLiteFile, runID)
+	}
+	if *outputJUnitFile != "" {
+		schedulerutils.RecordBuildSummaryJUnit(builtGraph, graphMutex, buildState, *outputJUnitFile)
+	}
+	if *outputMarkdown != "" {
+		schedulerutils.RecordBuildSummaryMarkdown(builtGraph, graphMutex, buildState, *outputMarkdown)
+	}
+	if *outputHTMLFile != "" {
+		schedulerutils.RecordBuildSummaryHTML(builtGraph, graphMutex, buildState, *outputHTMLFile)
+	}
+	if *outputPromFile != "" {
+		schedulerutils.RecordBuildSummaryPrometheus(builtGraph, graphMutex, buildState, *outputPromFile)
+	}
+	if *outputConflicts != "" {
+		schedulerutils.RecordConflictsSummary(buildState, allowToolchainRebuilds, *outputConflicts)
+	}
+	if *printCounts {
+		schedulerutils.PrintBuildCounts(os.Stdout, builtGraph, graphMutex, buildState)
+	}
+	if *failureGraph != "" {
+		schedulerutils.RecordFailureGraphDOT(builtGraph, graphMutex, buildState, *failureGraph)
+	}
+	if *failuresDigest != "" {
+		schedulerutils.RecordFailuresDigest(builtGraph, graphMutex, buildState, *failuresDigest)
+	}
+	status = schedulerutils.CalculateBuildStatus(builtGraph, graphMutex, buildState, allowToolchainRebuilds, *strictUnresolved)
+	if *regressionBase != "" {
+		regressed, regressionErr := schedulerutils.CheckBuildSummaryRegressions(*regressionBase, builtGraph, graphMutex, buildState)
+		if regressionErr != nil {
+			logger.Log.Errorf("Failed to check the build for regressions from baseline '%s'. Error: %s", *regressionBase, regressionErr)
+		} else if len(regressed) != 0 {
+			regressionErr = fmt.Errorf("%d package(s) built in baseline '%s' now failed: %s", len(regressed), *regressionBase, strings.Join(regressed, ", "))
+			logger.Log.Errorf("Build regressed from baseline. Error: %s", regressionErr)
+		}
+		if regressionErr != nil && err == nil {
+			err = regressionErr
+		}
+	}
+	if status.HasFatalUnresolved && err == nil {
+		err = fmt.Errorf("%d unresolved dependencies found. See build summary for details", status.UnresolvedCount)
+	}
+	if status.HasFatalConflicts {
 		err = fmt.Errorf("toolchain packages rebuilt. See build summary for details. Use 'ALLOW_TOOLCHAIN_REBUILDS=y' to suppress this error if rebuilds were expected")
 	}
 	return
 }
 
+// findPrebuiltAlternatives queries the repos defined in repoFiles for prebuilt versions of the failed SRPMs.
+// If the repos can't be queried, the error is logged and no alternatives are returned.
+func findPrebuiltAlternatives(pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, buildState *schedulerutils.GraphBuildState, repoFiles []string) (alternatives map[string][]string) {
+	queryDir := filepath.Join(*workDir, "prebuilt_alternatives")
+	cloner, err := rpmrepocloner.ConstructCloner(filepath.Join(queryDir, "rpms"), filepath.Join(queryDir, "chroot"), *workerTar, *rpmDir, *toolchainDirPath, "", "", repoFiles)
+	if err != nil {
+		logger.Log.Warnf("Unable to query the repos for prebuilt alternatives, error: %s.", err)
+		return
+	}
+	defer cloner.Close()
+
+	return schedulerutils.FindPrebuiltAlternatives(pkgGraph, graphMutex, buildState, cloner.WhatProvides)
+}
+
+// summaryCSVMetadata returns the metadata header of the summary csv, or nil if no build ID was provided.
+func summaryCSVMetadata(buildStartTime time.Time) *schedulerutils.SummaryMetadata {
+	if *csvBuildID == "" {
+		return nil
+	}
+
+	return &schedulerutils.SummaryMetadata{
+		BuildID:        *csvBuildID,
+		Timestamp:      buildStartTime,
+		ToolkitVersion: exe.ToolkitVersion,
+	}
+}
+
+// summaryCSVColumns returns the columns selected for the summary CSV file.
+// The Duration column is added to the default columns if --output-build-state-csv-durations is set and the Known Issue
+// column if --output-build-state-csv-known-issues is set. --summary-full-srpm-paths doesn't add a column, it names the
+// SRPMs of the existing columns by their full path.
+func summaryCSVColumns() (columns []string) {
+	if *csvColumns != "" {
+		return strings.Split(*csvColumns, ",")
+	}
+
+	columns = append(columns, schedulerutils.DefaultSummaryColumns...)
+	if *csvDurations {
+		columns = append(columns, "Duration")
+	}
+	if *csvKnownIssues != "" {
+		columns = append(columns, "Known Issue")
+	}
+
+	return
+}
+
 // updateGraphWithImplicitProvides will update the graph with new implicit provides if available.
 // It will also attempt to subgraph the graph if it becomes solvable with the new implicit provides.
 func updateGraphWithImplicitProvides(res *schedulerutils.BuildResult, pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, useCachedImplicit bool) (didOptimize bool, newGraph *pkggraph.PkgGraph, newGoalNode *pkggraph.PkgNode, err error) {
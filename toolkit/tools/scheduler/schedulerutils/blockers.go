@@ -0,0 +1,330 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/sliceutils"
+)
+
+// TraceBlockingRoot walks the dependencies of a blocked node to find the failed SRPM(s) that originally blocked it.
+// It returns one path per root failure. Each path starts with the requested node, ends with the failed build node and
+// only contains the build nodes along the way. Paths are sorted by the SRPM name of the root failure.
+// The walk keeps track of visited nodes so it terminates even if the graph contains a cycle.
+func TraceBlockingRoot(pkgGraph *pkggraph.PkgGraph, node *pkggraph.PkgNode, buildState *GraphBuildState) (blockingPaths [][]*pkggraph.PkgNode) {
+	parents := make(map[*pkggraph.PkgNode]*pkggraph.PkgNode)
+	visited := map[*pkggraph.PkgNode]bool{node: true}
+	queue := []*pkggraph.PkgNode{node}
+
+	var roots []*pkggraph.PkgNode
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		if current != node && isFailedBuildNode(current, buildState) {
+			// A failed node's dependencies were all available, so there is no need to walk any further.
+			roots = append(roots, current)
+			continue
+		}
+
+		dependencies := pkgGraph.From(current.ID())
+		for dependencies.Next() {
+			dependency := dependencies.Node().(*pkggraph.PkgNode)
+
+			// Available dependencies can't be blocking anything.
+			if visited[dependency] || buildState.IsNodeAvailable(dependency) {
+				continue
+			}
+
+			visited[dependency] = true
+			parents[dependency] = current
+			queue = append(queue, dependency)
+		}
+	}
+
+	sort.Slice(roots, func(i, j int) bool {
+		return roots[i].SRPMFileName() < roots[j].SRPMFileName()
+	})
+
+	for _, root := range roots {
+		var path []*pkggraph.PkgNode
+		for current := root; current != nil; current = parents[current] {
+			if current.Type == pkggraph.TypeLocalBuild || current == node {
+				path = append([]*pkggraph.PkgNode{current}, path...)
+			}
+		}
+		blockingPaths = append(blockingPaths, path)
+	}
+
+	return
+}
+
+// isFailedBuildNode returns true if the node is a build node which failed to build.
+func isFailedBuildNode(node *pkggraph.PkgNode, buildState *GraphBuildState) bool {
+	return node.Type == pkggraph.TypeLocalBuild && buildState.DidNodeFail(node)
+}
+
+// formatBlockingPaths formats blocking paths as "a.src.rpm -> b.src.rpm" chains separated by "; ", naming the SRPMs with srpmName.
+func formatBlockingPaths(blockingPaths [][]*pkggraph.PkgNode, srpmName func(node *pkggraph.PkgNode) string) string {
+	formattedPaths := make([]string, 0, len(blockingPaths))
+	for _, path := range blockingPaths {
+		srpmNames := make([]string, 0, len(path))
+		for _, node := range path {
+			// Consecutive build nodes may come from the same SRPM, only list it once.
+			name := srpmName(node)
+			if len(srpmNames) == 0 || srpmNames[len(srpmNames)-1] != name {
+				srpmNames = append(srpmNames, name)
+			}
+		}
+		formattedPaths = append(formattedPaths, strings.Join(srpmNames, " -> "))
+	}
+
+	return strings.Join(formattedPaths, "; ")
+}
+
+// blockerKind describes why a dependency prevents a node from being built.
+type blockerKind int
+
+const (
+	blockerFailed     blockerKind = iota // The dependency's SRPM failed to build
+	blockerUnbuilt    blockerKind = iota // The dependency's SRPM was itself blocked
+	blockerUnresolved blockerKind = iota // The dependency could not be resolved
+)
+
+// nodeBlocker is a single dependency which prevents a node from being built.
+type nodeBlocker struct {
+	node *pkggraph.PkgNode
+	kind blockerKind
+}
+
+// directBlockers walks the nodes a node directly depends on and returns the ones preventing it from being built.
+func directBlockers(pkgGraph *pkggraph.PkgGraph, node *pkggraph.PkgNode, categories *BuildNodeCategories) (blockers []nodeBlocker) {
+	fromNodes := pkgGraph.From(node.ID())
+	for fromNodes.Next() {
+		fromNode := fromNodes.Node().(*pkggraph.PkgNode)
+		if fromNode.State == pkggraph.StateUnresolved {
+			blockers = append(blockers, nodeBlocker{node: fromNode, kind: blockerUnresolved})
+			continue
+		}
+		if _, found := categories.Failed[fromNode.SrpmPath]; found {
+			blockers = append(blockers, nodeBlocker{node: fromNode, kind: blockerFailed})
+		}
+		if _, found := categories.Unbuilt[fromNode.SrpmPath]; found {
+			blockers = append(blockers, nodeBlocker{node: fromNode, kind: blockerUnbuilt})
+		}
+	}
+
+	return
+}
+
+// blockingSRPMs returns the sorted, unique base names of all failed or unbuilt SRPMs the node directly depends on.
+func blockingSRPMs(pkgGraph *pkggraph.PkgGraph, node *pkggraph.PkgNode, categories *BuildNodeCategories) (blockers []string) {
+	blockerSet := make(map[string]bool)
+	for _, blocker := range directBlockers(pkgGraph, node, categories) {
+		if blocker.kind != blockerUnresolved {
+			blockerSet[filepath.Base(blocker.node.SrpmPath)] = true
+		}
+	}
+
+	blockers = sliceutils.SetToSlice(blockerSet)
+	sort.Strings(blockers)
+
+	return
+}
+
+// csvBlockers returns the CSV Blocker column for a node: the failed and unbuilt SRPMs it directly depends on.
+// A blocker reachable through multiple edges is only listed once, blockers are sorted for a stable output.
+// The blocking SRPMs are named with srpmName.
+func csvBlockers(pkgGraph *pkggraph.PkgGraph, node *pkggraph.PkgNode, categories *BuildNodeCategories, srpmName func(node *pkggraph.PkgNode) string) (blockers string) {
+	blockerSet := make(map[string]bool)
+	for _, blocker := range directBlockers(pkgGraph, node, categories) {
+		switch blocker.kind {
+		case blockerFailed:
+			blockerSet[srpmName(blocker.node)+"-FAIL"] = true
+		case blockerUnbuilt:
+			blockerSet[srpmName(blocker.node)+"-UNBUILT"] = true
+		}
+	}
+
+	sortedBlockers := sliceutils.SetToSlice(blockerSet)
+	sort.Strings(sortedBlockers)
+	for _, blocker := range sortedBlockers {
+		blockers += blocker + " "
+	}
+
+	return
+}
+
+// blockingCauses records the root causes which prevent a blocked node from being built.
+type blockingCauses struct {
+	failure    bool
+	unresolved bool
+}
+
+// String returns a human readable description of the blocking causes.
+func (c blockingCauses) String() string {
+	switch {
+	case c.failure && c.unresolved:
+		return "failure and unresolved dependency"
+	case c.failure:
+		return "failure"
+	case c.unresolved:
+		return "unresolved dependency"
+	default:
+		return "unknown"
+	}
+}
+
+// findBlockingCauses determines whether a blocked node is ultimately blocked by a failed build, an unresolved dependency or both.
+// Blocked dependencies are followed until a failure or unresolved dependency is found.
+// - visited caches the causes of each visited build node, so the graph is walked once per summary.
+func findBlockingCauses(pkgGraph *pkggraph.PkgGraph, node *pkggraph.PkgNode, categories *BuildNodeCategories, visited map[*pkggraph.PkgNode]blockingCauses) (causes blockingCauses) {
+	if cached, found := visited[node]; found {
+		return cached
+	}
+	// Guard against dependency cycles between blocked nodes.
+	visited[node] = causes
+
+	for _, blocker := range directBlockers(pkgGraph, node, categories) {
+		switch blocker.kind {
+		case blockerFailed:
+			causes.failure = true
+		case blockerUnresolved:
+			causes.unresolved = true
+		case blockerUnbuilt:
+			dependencyCauses := findBlockingCauses(pkgGraph, categories.Unbuilt[blocker.node.SrpmPath], categories, visited)
+			causes.failure = causes.failure || dependencyCauses.failure
+			causes.unresolved = causes.unresolved || dependencyCauses.unresolved
+		}
+	}
+
+	visited[node] = causes
+	return
+}
+
+// failureImpact is a failed SRPM and the number of SRPMs its failure transitively blocked.
+type failureImpact struct {
+	failure      *BuildResult
+	blockedSRPMs int
+}
+
+// countBlockedByFailure walks the nodes depending on a failed build and returns the number of unique SRPMs which were
+// transitively blocked by it. Dependents which were processed either built or failed on their own, so the walk does not
+// continue past them.
+func countBlockedByFailure(pkgGraph *pkggraph.PkgGraph, failure *BuildResult, buildState *GraphBuildState) int {
+	visited := make(map[*pkggraph.PkgNode]bool)
+	blockedSRPMs := make(map[string]bool)
+
+	queue := append([]*pkggraph.PkgNode{failure.Node}, failure.AncillaryNodes...)
+	for _, node := range queue {
+		visited[node] = true
+	}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		dependents := pkgGraph.To(current.ID())
+		for dependents.Next() {
+			dependent := dependents.Node().(*pkggraph.PkgNode)
+			if visited[dependent] || buildState.IsNodeProcessed(dependent) {
+				continue
+			}
+
+			visited[dependent] = true
+			if dependent.Type == pkggraph.TypeLocalBuild && dependent.SrpmPath != failure.Node.SrpmPath {
+				blockedSRPMs[dependent.SrpmPath] = true
+			}
+			queue = append(queue, dependent)
+		}
+	}
+
+	return len(blockedSRPMs)
+}
+
+// failuresByImpact returns the failures sorted by the number of SRPMs they blocked, most impactful first.
+// Failures with the same impact are sorted by SRPM name.
+func failuresByImpact(pkgGraph *pkggraph.PkgGraph, failures []*BuildResult, buildState *GraphBuildState) (impacts []failureImpact) {
+	for _, failure := range sortedFailures(failures) {
+		impacts = append(impacts, failureImpact{
+			failure:      failure,
+			blockedSRPMs: countBlockedByFailure(pkgGraph, failure, buildState),
+		})
+	}
+
+	sort.SliceStable(impacts, func(i, j int) bool {
+		return impacts[i].blockedSRPMs > impacts[j].blockedSRPMs
+	})
+
+	return
+}
+
+// dependentSRPMs walks the nodes depending on a failed build and returns the nodes of the SRPMs which directly depend
+// on it, keyed by SRPM path. The walk continues through run and meta nodes, but stops at the build nodes of other SRPMs.
+func dependentSRPMs(pkgGraph *pkggraph.PkgGraph, failure *BuildResult) (dependents map[string]*pkggraph.PkgNode) {
+	dependents = make(map[string]*pkggraph.PkgNode)
+	visited := make(map[*pkggraph.PkgNode]bool)
+
+	queue := append([]*pkggraph.PkgNode{failure.Node}, failure.AncillaryNodes...)
+	for _, node := range queue {
+		visited[node] = true
+	}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		dependentNodes := pkgGraph.To(current.ID())
+		for dependentNodes.Next() {
+			dependent := dependentNodes.Node().(*pkggraph.PkgNode)
+			if visited[dependent] {
+				continue
+			}
+
+			visited[dependent] = true
+			if dependent.Type == pkggraph.TypeLocalBuild && dependent.SrpmPath != failure.Node.SrpmPath {
+				dependents[dependent.SrpmPath] = dependent
+				continue
+			}
+			queue = append(queue, dependent)
+		}
+	}
+
+	return
+}
+
+// nonBlockingFailures returns the failed builds whose dependent SRPMs were all built regardless, e.g. because they
+// only weakly depend on the failed package, along with the names of those built SRPMs as given by srpmName.
+// Failures without any dependent SRPMs are not included. Sorted by SRPM name.
+func nonBlockingFailures(pkgGraph *pkggraph.PkgGraph, categories *BuildNodeCategories, srpmName func(node *pkggraph.PkgNode) string) (failures []*BuildResult, builtDependents map[*BuildResult][]string) {
+	builtDependents = make(map[*BuildResult][]string)
+
+	for _, failure := range sortedFailures(categories.Failures) {
+		dependents := dependentSRPMs(pkgGraph, failure)
+		if len(dependents) == 0 {
+			continue
+		}
+
+		allBuilt := true
+		for srpmPath := range dependents {
+			if _, built := categories.Built[srpmPath]; !built {
+				allBuilt = false
+				break
+			}
+		}
+
+		if allBuilt {
+			failures = append(failures, failure)
+			for _, dependent := range sortedNodes(dependents) {
+				builtDependents[failure] = append(builtDependents[failure], srpmName(dependent))
+			}
+		}
+	}
+
+	return
+}
@@ -0,0 +1,105 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkgjson"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTraceBlockingRootFindsTransitiveFailure(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+
+	paths := TraceBlockingRoot(g, buildNodes["blocked2"], buildState)
+
+	assert.Equal(t, [][]*pkggraph.PkgNode{{buildNodes["blocked2"], buildNodes["blocked"], buildNodes["failed"]}}, paths)
+	assert.Equal(t, "blocked2-1.0-1.cm2.src.rpm -> blocked-1.0-1.cm2.src.rpm -> failed-1.0-1.cm2.src.rpm", formatBlockingPaths(paths, (*pkggraph.PkgNode).SRPMFileName))
+}
+
+func TestTraceBlockingRootTerminatesOnCycle(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+
+	// Close a cycle between the two blocked packages.
+	blockedRun, err := g.FindBestPkgNode(&pkgjson.PackageVer{Name: "blocked2"})
+	assert.NoError(t, err)
+	assert.NoError(t, g.AddEdge(buildNodes["blocked"], blockedRun.RunNode))
+
+	paths := TraceBlockingRoot(g, buildNodes["blocked"], buildState)
+	assert.Equal(t, [][]*pkggraph.PkgNode{{buildNodes["blocked"], buildNodes["failed"]}}, paths)
+}
+
+func TestPrintBuildSummaryToAnnotatesBlockingReason(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+
+	summary := output.String()
+	assert.Contains(t, summary, "--> blocked-1.0-1.cm2.src.rpm (blocked by failure)\n")
+	assert.Contains(t, summary, "--> blocked2-1.0-1.cm2.src.rpm (blocked by failure and unresolved dependency)\n")
+}
+
+func TestPrintBuildSummaryToSortsFailuresByBlockedSRPMs(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+	// "blocked2" now fails on its own, it blocks nothing while "failed" still blocks "blocked".
+	recordTestResult(buildState, buildNodes["blocked2"], false, false, fmt.Errorf("build failed"))
+
+	impacts := failuresByImpact(g, buildState.BuildFailures(), buildState)
+	assert.Len(t, impacts, 2)
+	assert.Equal(t, buildNodes["failed"], impacts[0].failure.Node)
+	assert.Equal(t, 1, impacts[0].blockedSRPMs)
+	assert.Equal(t, buildNodes["blocked2"], impacts[1].failure.Node)
+	assert.Equal(t, 0, impacts[1].blockedSRPMs)
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+	assert.Contains(t, output.String(), "unblocks the most packages):\n--> failed-1.0-1.cm2.src.rpm (blocks 1 SRPMs)\nBlocked SRPMs:")
+}
+
+func TestRecordBuildSummaryDeduplicatesBlockers(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+
+	// Depend on a second RPM of the failed SRPM, so the failure is reachable through two edges.
+	develNode, err := g.AddPkgNode(&pkgjson.PackageVer{Name: "failed-devel", Version: "1.0-1.cm2"}, pkggraph.StateMeta, pkggraph.TypeLocalRun, buildNodes["failed"].SrpmPath, "/mariner/out/RPMS/x86_64/failed-devel-1.0-1.cm2.x86_64.rpm", buildNodes["failed"].SpecPath, buildNodes["failed"].SourceDir, "x86_64", "<LOCAL>")
+	assert.NoError(t, err)
+	assert.NoError(t, g.AddEdge(develNode, buildNodes["failed"]))
+	assert.NoError(t, g.AddEdge(buildNodes["blocked"], develNode))
+
+	outputPath := filepath.Join(t.TempDir(), "summary.csv")
+	RecordBuildSummary(g, &sync.RWMutex{}, buildState, outputPath, SummaryCSVOptions{})
+
+	contents, err := os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(contents), "blocked-1.0-1.cm2.src.rpm,Unbuilt,failed-1.0-1.cm2.src.rpm-FAIL \n")
+	assert.Equal(t, []string{"failed-1.0-1.cm2.src.rpm"}, blockingSRPMs(g, buildNodes["blocked"], CategorizeBuildNodes(g, buildState)))
+}
+
+func TestBuildSummaryListsNonBlockingFailures(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+
+	// "failed" blocks "blocked", so it cascaded.
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+	assert.NotContains(t, output.String(), "Non-blocking failures")
+
+	// "docs" failed, but "app" which weakly depends on it still built.
+	docsRun, docsBuild := addTestPackage(t, g, "docs")
+	_, appBuild := addTestPackage(t, g, "app")
+	assert.NoError(t, g.AddEdge(appBuild, docsRun))
+	recordTestResult(buildState, docsBuild, false, false, fmt.Errorf("build failed"))
+	recordTestResult(buildState, appBuild, false, false, nil)
+
+	output.Reset()
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+	assert.Contains(t, output.String(), "Non-blocking failures (i.e., failed SRPMs whose dependents still built):\n--> docs-1.0-1.cm2.src.rpm (built dependents: app-1.0-1.cm2.src.rpm)\n")
+	assert.NotContains(t, output.String(), "--> failed-1.0-1.cm2.src.rpm (built dependents")
+}
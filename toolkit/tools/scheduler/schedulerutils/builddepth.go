@@ -0,0 +1,58 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+)
+
+// CalculateBuildDepths returns the topological depth of every node in the graph, which is the number of build nodes
+// on the longest chain of dependencies below it. Build nodes of leaf packages, which only depend on packages that
+// don't have to be built, are at depth 0 and each package is one deeper than the deepest package it requires to build.
+// Nodes of disconnected components without any build dependencies are at depth 0.
+// Dependency cycles are broken arbitrarily. The caller is responsible for holding the graph's read lock.
+func CalculateBuildDepths(pkgGraph *pkggraph.PkgGraph) (depths map[*pkggraph.PkgNode]int) {
+	// levels holds the number of build nodes on the longest chain starting at each node, including the node itself.
+	levels := make(map[*pkggraph.PkgNode]int)
+
+	var levelsFrom func(node *pkggraph.PkgNode) int
+	levelsFrom = func(node *pkggraph.PkgNode) int {
+		if nodeLevels, found := levels[node]; found {
+			return nodeLevels
+		}
+		// Guard against dependency cycles, a node already on the stack adds nothing.
+		levels[node] = 0
+
+		nodeLevels := 0
+		dependencies := pkgGraph.From(node.ID())
+		for dependencies.Next() {
+			dependency := dependencies.Node().(*pkggraph.PkgNode)
+			if dependencyLevels := levelsFrom(dependency); dependencyLevels > nodeLevels {
+				nodeLevels = dependencyLevels
+			}
+		}
+
+		if node.Type == pkggraph.TypeLocalBuild {
+			nodeLevels++
+		}
+		levels[node] = nodeLevels
+
+		return nodeLevels
+	}
+
+	depths = make(map[*pkggraph.PkgNode]int)
+	for _, node := range pkgGraph.AllNodes() {
+		// Only count the build nodes below a build node, it doesn't depend on itself.
+		depth := levelsFrom(node)
+		if node.Type == pkggraph.TypeLocalBuild {
+			depth--
+		}
+		if depth < 0 {
+			depth = 0
+		}
+		depths[node] = depth
+	}
+
+	return
+}
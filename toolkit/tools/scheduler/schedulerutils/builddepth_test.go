@@ -0,0 +1,29 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateBuildDepths(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+
+	depths := CalculateBuildDepths(g)
+	assert.Equal(t, 0, depths[buildNodes["built"]])
+	assert.Equal(t, 0, depths[buildNodes["failed"]])
+	assert.Equal(t, 1, depths[buildNodes["blocked"]])
+	assert.Equal(t, 2, depths[buildNodes["blocked2"]])
+
+	outputPath := filepath.Join(t.TempDir(), "summary.csv")
+	RecordBuildSummary(g, &sync.RWMutex{}, buildState, outputPath, SummaryCSVOptions{Columns: []string{"Package", "Depth"}})
+	contents, err := os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(contents), "\nblocked-1.0-1.cm2.src.rpm,1\nblocked2-1.0-1.cm2.src.rpm,2\nbuilt-1.0-1.cm2.src.rpm,0\n")
+}
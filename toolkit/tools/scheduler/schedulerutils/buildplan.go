@@ -0,0 +1,133 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"sync"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/logger"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkgjson"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/sliceutils"
+)
+
+// plannedAction is what a build is expected to do with an SRPM.
+type plannedAction int
+
+const (
+	planBuild            plannedAction = iota // The SRPM would be sent to a build agent
+	planUseCache         plannedAction = iota // All of the SRPM's RPMs are present and would be used as-is
+	planAlreadySatisfied plannedAction = iota // The SRPM was already processed or pre-downloaded in delta mode
+)
+
+// BuildPlan groups the build nodes of a graph by what a build is expected to do with them.
+// Each node map is keyed by the SRPM path of its nodes, every SRPM is found in exactly one of them.
+type BuildPlan struct {
+	WouldBuild       map[string]*pkggraph.PkgNode
+	WouldUseCache    map[string]*pkggraph.PkgNode
+	AlreadySatisfied map[string]*pkggraph.PkgNode
+}
+
+// PreviewBuildPlan predicts what a build of the graph would do with each SRPM without building anything, and prints
+// the counts. It follows the same rules as the scheduler, starting from the nodes CategorizeBuildNodes reports as unbuilt:
+// - SRPMs which were already processed in buildState or pre-downloaded in delta mode are already satisfied.
+// - SRPMs with all of their RPMs present would use the cache, unless the cache is not allowed, they are listed in
+// packagesToRebuild or one of their dependencies would be built.
+// - Every other SRPM would be built.
+func PreviewBuildPlan(pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, buildState *GraphBuildState, packagesToRebuild []*pkgjson.PackageVer, isCacheAllowed bool) (plan *BuildPlan) {
+	categories := GetBuildSummary(pkgGraph, graphMutex, buildState)
+
+	graphMutex.RLock()
+	defer graphMutex.RUnlock()
+
+	plan = &BuildPlan{
+		WouldBuild:       make(map[string]*pkggraph.PkgNode),
+		WouldUseCache:    make(map[string]*pkggraph.PkgNode),
+		AlreadySatisfied: make(map[string]*pkggraph.PkgNode),
+	}
+
+	srpmBuildNodes := make(map[string][]*pkggraph.PkgNode)
+	for _, node := range pkgGraph.AllBuildNodes() {
+		srpmBuildNodes[node.SrpmPath] = append(srpmBuildNodes[node.SrpmPath], node)
+	}
+
+	actions := make(map[string]plannedAction)
+	var planSRPM func(srpmPath string) plannedAction
+	planSRPM = func(srpmPath string) (action plannedAction) {
+		if knownAction, found := actions[srpmPath]; found {
+			return knownAction
+		}
+		// Assume SRPMs in a dependency cycle don't force each other to be rebuilt.
+		actions[srpmPath] = planUseCache
+		defer func() {
+			actions[srpmPath] = action
+		}()
+
+		nodes := srpmBuildNodes[srpmPath]
+		if _, unbuilt := categories.Unbuilt[srpmPath]; !unbuilt {
+			if _, failed := categories.Failed[srpmPath]; !failed {
+				return planAlreadySatisfied
+			}
+		}
+
+		for _, node := range nodes {
+			if node.State == pkggraph.StateDelta {
+				return planAlreadySatisfied
+			}
+		}
+
+		if !isCacheAllowed || sliceutils.Contains(packagesToRebuild, nodes[0].VersionedPkg, sliceutils.PackageVerMatch) {
+			return planBuild
+		}
+
+		// The graph lock is already held, don't let IsSRPMPrebuilt take it again.
+		isPrebuilt, _, _ := pkggraph.IsSRPMPrebuilt(srpmPath, pkgGraph, nil)
+		if !isPrebuilt {
+			return planBuild
+		}
+
+		for _, node := range nodes {
+			dependencies := pkgGraph.From(node.ID())
+			for dependencies.Next() {
+				dependency := dependencies.Node().(*pkggraph.PkgNode)
+				if dependency.Type != pkggraph.TypeLocalRun || dependency.SrpmPath == srpmPath {
+					continue
+				}
+
+				if planSRPM(dependency.SrpmPath) == planBuild {
+					return planBuild
+				}
+			}
+		}
+
+		return planUseCache
+	}
+
+	for srpmPath, nodes := range srpmBuildNodes {
+		switch planSRPM(srpmPath) {
+		case planBuild:
+			plan.WouldBuild[srpmPath] = nodes[0]
+		case planUseCache:
+			plan.WouldUseCache[srpmPath] = nodes[0]
+		case planAlreadySatisfied:
+			plan.AlreadySatisfied[srpmPath] = nodes[0]
+		}
+	}
+
+	logger.Log.Info("---------------------------")
+	logger.Log.Info("-------- Build plan -------")
+	logger.Log.Info("---------------------------")
+	logger.Log.Infof("Number of SRPMs which would be built:          %d", len(plan.WouldBuild))
+	logger.Log.Infof("Number of SRPMs which would use the cache:     %d", len(plan.WouldUseCache))
+	logger.Log.Infof("Number of SRPMs which are already satisfied:   %d", len(plan.AlreadySatisfied))
+
+	if len(plan.WouldBuild) != 0 {
+		logger.Log.Debug("SRPMs which would be built:")
+		for _, srpm := range sortedSRPMNames(plan.WouldBuild) {
+			logger.Log.Debugf("--> %s", srpm)
+		}
+	}
+
+	return
+}
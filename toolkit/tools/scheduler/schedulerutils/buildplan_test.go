@@ -0,0 +1,43 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkgjson"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreviewBuildPlan(t *testing.T) {
+	g := pkggraph.NewPkgGraph()
+	rpmDir := t.TempDir()
+
+	runNodes := make(map[string]*pkggraph.PkgNode)
+	buildNodes := make(map[string]*pkggraph.PkgNode)
+	for _, name := range []string{"base", "app", "lib", "tool", "delta"} {
+		runNodes[name], buildNodes[name] = addTestPackage(t, g, name)
+		runNodes[name].RpmPath = filepath.Join(rpmDir, filepath.Base(runNodes[name].RpmPath))
+	}
+	buildNodes["delta"].State = pkggraph.StateDelta
+
+	// Every package except "lib" has its RPM present.
+	for _, name := range []string{"base", "app", "tool"} {
+		assert.NoError(t, os.WriteFile(runNodes[name].RpmPath, []byte{}, 0644))
+	}
+	assert.NoError(t, g.AddEdge(buildNodes["app"], runNodes["base"]))
+	assert.NoError(t, g.AddEdge(buildNodes["tool"], runNodes["lib"]))
+
+	plan := PreviewBuildPlan(g, &sync.RWMutex{}, NewGraphBuildState(nil), nil, true)
+	assert.Equal(t, []string{"lib-1.0-1.cm2.src.rpm", "tool-1.0-1.cm2.src.rpm"}, sortedSRPMNames(plan.WouldBuild))
+	assert.Equal(t, []string{"app-1.0-1.cm2.src.rpm", "base-1.0-1.cm2.src.rpm"}, sortedSRPMNames(plan.WouldUseCache))
+	assert.Equal(t, []string{"delta-1.0-1.cm2.src.rpm"}, sortedSRPMNames(plan.AlreadySatisfied))
+
+	plan = PreviewBuildPlan(g, &sync.RWMutex{}, NewGraphBuildState(nil), []*pkgjson.PackageVer{{Name: "base", Version: "1.0-1.cm2"}}, true)
+	assert.Equal(t, []string{"app-1.0-1.cm2.src.rpm", "base-1.0-1.cm2.src.rpm", "lib-1.0-1.cm2.src.rpm", "tool-1.0-1.cm2.src.rpm"}, sortedSRPMNames(plan.WouldBuild))
+}
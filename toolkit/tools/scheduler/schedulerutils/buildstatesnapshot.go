@@ -0,0 +1,288 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/jsonutils"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/sliceutils"
+)
+
+// buildStateSnapshot is the serialized form of a GraphBuildState.
+// The cached, available and failed state of each node is derived from its build result, so only the results are stored.
+type buildStateSnapshot struct {
+	ReservedFiles []string              `json:"reservedFiles"`
+	Results       []buildResultSnapshot `json:"results"`
+}
+
+// nodeSnapshot references a node of the graph file. Node IDs are not preserved when a graph file is written and read
+// back, so nodes are referenced by their type, SRPM and package instead.
+type nodeSnapshot struct {
+	Type     string `json:"type"`
+	SrpmPath string `json:"srpmPath"`
+	Package  string `json:"package"`
+}
+
+// buildResultSnapshot is the serialized form of a BuildResult.
+type buildResultSnapshot struct {
+	Node            nodeSnapshot    `json:"node"`
+	AncillaryNodes  []nodeSnapshot  `json:"ancillaryNodes"`
+	Attempts        int             `json:"attempts"`
+	BuiltFiles      []string        `json:"builtFiles"`
+	CacheAvailable  bool            `json:"cacheAvailable"`
+	CacheMayBeStale bool            `json:"cacheMayBeStale"`
+	CacheMissReason CacheMissReason `json:"cacheMissReason"`
+	CacheSource     string          `json:"cacheSource,omitempty"`
+	Cores           int             `json:"cores"`
+	Duration        time.Duration   `json:"duration"`
+	EndTime         time.Time       `json:"endTime"`
+	Err             string          `json:"err,omitempty"`
+	FailureLine     int             `json:"failureLine"`
+	FailureMessage  string          `json:"failureMessage,omitempty"`
+	FailureType     FailureType     `json:"failureType"`
+	LogFile         string          `json:"logFile"`
+	PeakRSS         int64           `json:"peakRSS"`
+	QueuedTime      time.Time       `json:"queuedTime"`
+	RebuiltDep      *nodeSnapshot   `json:"rebuiltDep,omitempty"`
+	Skipped         bool            `json:"skipped"`
+	StartTime       time.Time       `json:"startTime"`
+	TimedOut        bool            `json:"timedOut"`
+	UsedCache       bool            `json:"usedCache"`
+	WasDelta        bool            `json:"wasDelta"`
+}
+
+// SaveGraphBuildState stores the recorded build results of buildState in to a JSON file, so the summary of the build
+// can be regenerated offline from it and the built graph file with LoadGraphBuildState.
+// Active builds and callbacks are not saved. If outputPath is "-", the JSON is written to stdout.
+func SaveGraphBuildState(buildState *GraphBuildState, outputPath string) (err error) {
+	snapshot := buildStateSnapshot{
+		ReservedFiles: sliceutils.SetToSlice(buildState.reservedFiles),
+		Results:       make([]buildResultSnapshot, 0),
+	}
+	sort.Strings(snapshot.ReservedFiles)
+
+	// Failures are stored first, in the order they were recorded, since the summary lists them in that order.
+	var results []*BuildResult
+	savedResults := make(map[*BuildResult]bool)
+	for _, failure := range buildState.failures {
+		if !savedResults[failure] {
+			savedResults[failure] = true
+			results = append(results, failure)
+		}
+	}
+
+	var otherResults []*BuildResult
+	for _, state := range buildState.nodeToState {
+		if !savedResults[state.result] {
+			savedResults[state.result] = true
+			otherResults = append(otherResults, state.result)
+		}
+	}
+	// Nodes sharing a result are mapped to the same pointer, sort the remaining results for a stable file.
+	sort.Slice(otherResults, func(i, j int) bool {
+		return otherResults[i].Node.ID() < otherResults[j].Node.ID()
+	})
+	results = append(results, otherResults...)
+
+	for _, res := range results {
+		snapshot.Results = append(snapshot.Results, newBuildResultSnapshot(res))
+	}
+
+	return writeJSONReport(snapshot, outputPath)
+}
+
+// LoadGraphBuildState reads a JSON file written by SaveGraphBuildState and replays its build results on to a new
+// GraphBuildState for pkgGraph. pkgGraph must be the graph file written by the same build, an error is returned if a
+// node of the build state is not found in it or matches several of its nodes.
+func LoadGraphBuildState(pkgGraph *pkggraph.PkgGraph, inputPath string) (buildState *GraphBuildState, err error) {
+	var snapshot buildStateSnapshot
+	err = jsonutils.ReadJSONFile(inputPath, &snapshot)
+	if err != nil {
+		err = fmt.Errorf("failed to read build state '%s':\n%w", inputPath, err)
+		return
+	}
+
+	nodes := newSnapshotNodeIndex(pkgGraph)
+	buildState = NewGraphBuildState(snapshot.ReservedFiles)
+	for _, resSnapshot := range snapshot.Results {
+		var res *BuildResult
+		res, err = resSnapshot.toBuildResult(nodes)
+		if err != nil {
+			err = fmt.Errorf("failed to restore build state '%s':\n%w", inputPath, err)
+			return
+		}
+		buildState.RecordBuildResult(res)
+	}
+
+	return
+}
+
+// RegenerateBuildSummary loads a built graph file and a build state saved by SaveGraphBuildState, prints the build
+// summary and records it in to a csv at csvOutputPath, as if the build had just finished.
+// - columns selects the csv columns, see RecordBuildSummary.
+func RegenerateBuildSummary(graphFile, buildStateFile, csvOutputPath string, columns []string) (err error) {
+	const allowToolchainRebuilds = false
+
+	pkgGraph, err := pkggraph.ReadDOTGraphFile(graphFile)
+	if err != nil {
+		err = fmt.Errorf("failed to read graph '%s':\n%w", graphFile, err)
+		return
+	}
+
+	buildState, err := LoadGraphBuildState(pkgGraph, buildStateFile)
+	if err != nil {
+		return
+	}
+
+	graphMutex := &sync.RWMutex{}
+	PrintBuildSummary(pkgGraph, graphMutex, buildState, allowToolchainRebuilds, SummaryOptions{})
+	RecordBuildSummary(pkgGraph, graphMutex, buildState, csvOutputPath, SummaryCSVOptions{Columns: columns})
+
+	return
+}
+
+// newBuildResultSnapshot returns the serialized form of a build result.
+func newBuildResultSnapshot(res *BuildResult) (snapshot buildResultSnapshot) {
+	snapshot = buildResultSnapshot{
+		Node:            newNodeSnapshot(res.Node),
+		AncillaryNodes:  make([]nodeSnapshot, 0, len(res.AncillaryNodes)),
+		Attempts:        res.Attempts,
+		BuiltFiles:      res.BuiltFiles,
+		CacheAvailable:  res.CacheAvailable,
+		CacheMayBeStale: res.CacheMayBeStale,
+		CacheMissReason: res.CacheMissReason,
+		CacheSource:     res.CacheSource,
+		Cores:           res.Cores,
+		Duration:        res.Duration,
+		EndTime:         res.EndTime,
+		FailureLine:     res.FailureLine,
+		FailureMessage:  res.FailureMessage,
+		FailureType:     res.FailureType,
+		LogFile:         res.LogFile,
+		PeakRSS:         res.PeakRSS,
+		QueuedTime:      res.QueuedTime,
+		Skipped:         res.Skipped,
+		StartTime:       res.StartTime,
+		TimedOut:        res.TimedOut,
+		UsedCache:       res.UsedCache,
+		WasDelta:        res.WasDelta,
+	}
+
+	for _, node := range res.AncillaryNodes {
+		snapshot.AncillaryNodes = append(snapshot.AncillaryNodes, newNodeSnapshot(node))
+	}
+
+	if res.Err != nil {
+		snapshot.Err = res.Err.Error()
+	}
+
+	if res.RebuiltDep != nil {
+		rebuiltDep := newNodeSnapshot(res.RebuiltDep)
+		snapshot.RebuiltDep = &rebuiltDep
+	}
+
+	return
+}
+
+// toBuildResult restores a build result, looking its nodes up in the graph. The original error is restored as a plain error.
+func (s *buildResultSnapshot) toBuildResult(nodes snapshotNodeIndex) (res *BuildResult, err error) {
+	res = &BuildResult{
+		Attempts:        s.Attempts,
+		BuiltFiles:      s.BuiltFiles,
+		CacheAvailable:  s.CacheAvailable,
+		CacheMayBeStale: s.CacheMayBeStale,
+		CacheMissReason: s.CacheMissReason,
+		CacheSource:     s.CacheSource,
+		Cores:           s.Cores,
+		Duration:        s.Duration,
+		EndTime:         s.EndTime,
+		FailureLine:     s.FailureLine,
+		FailureMessage:  s.FailureMessage,
+		FailureType:     s.FailureType,
+		LogFile:         s.LogFile,
+		PeakRSS:         s.PeakRSS,
+		QueuedTime:      s.QueuedTime,
+		Skipped:         s.Skipped,
+		StartTime:       s.StartTime,
+		TimedOut:        s.TimedOut,
+		UsedCache:       s.UsedCache,
+		WasDelta:        s.WasDelta,
+	}
+
+	res.Node, err = nodes.find(s.Node)
+	if err != nil {
+		return
+	}
+
+	for _, nodeSnapshot := range s.AncillaryNodes {
+		var node *pkggraph.PkgNode
+		node, err = nodes.find(nodeSnapshot)
+		if err != nil {
+			return
+		}
+		res.AncillaryNodes = append(res.AncillaryNodes, node)
+	}
+
+	if s.RebuiltDep != nil {
+		res.RebuiltDep, err = nodes.find(*s.RebuiltDep)
+		if err != nil {
+			return
+		}
+	}
+
+	if s.Err != "" {
+		res.Err = errors.New(s.Err)
+	}
+
+	return
+}
+
+// newNodeSnapshot returns the reference to a node stored in a snapshot.
+func newNodeSnapshot(node *pkggraph.PkgNode) (snapshot nodeSnapshot) {
+	snapshot = nodeSnapshot{
+		Type:     node.Type.String(),
+		SrpmPath: node.SrpmPath,
+	}
+
+	if node.VersionedPkg != nil {
+		snapshot.Package = node.VersionedPkg.String()
+	}
+
+	return
+}
+
+// snapshotNodeIndex maps the node references of a snapshot to the matching nodes of a graph.
+type snapshotNodeIndex map[nodeSnapshot][]*pkggraph.PkgNode
+
+// newSnapshotNodeIndex indexes all nodes of pkgGraph by their snapshot reference.
+func newSnapshotNodeIndex(pkgGraph *pkggraph.PkgGraph) (nodes snapshotNodeIndex) {
+	nodes = make(snapshotNodeIndex)
+	for _, node := range pkgGraph.AllNodes() {
+		reference := newNodeSnapshot(node)
+		nodes[reference] = append(nodes[reference], node)
+	}
+
+	return
+}
+
+// find returns the only node of the graph matching the reference.
+func (nodes snapshotNodeIndex) find(reference nodeSnapshot) (node *pkggraph.PkgNode, err error) {
+	matches := nodes[reference]
+	switch len(matches) {
+	case 0:
+		err = fmt.Errorf("%s node of '%s' from '%s' not found in the graph", reference.Type, reference.Package, reference.SrpmPath)
+	case 1:
+		node = matches[0]
+	default:
+		err = fmt.Errorf("%s node of '%s' from '%s' matches %d nodes of the graph", reference.Type, reference.Package, reference.SrpmPath, len(matches))
+	}
+
+	return
+}
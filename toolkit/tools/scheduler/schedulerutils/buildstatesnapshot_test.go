@@ -0,0 +1,96 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+	"github.com/stretchr/testify/assert"
+)
+
+// reloadTestGraph writes a graph to a DOT file and reads it back, as RegenerateBuildSummary does with the graph file
+// of a finished build. Node IDs are not preserved.
+func reloadTestGraph(t *testing.T, g *pkggraph.PkgGraph) (reloaded *pkggraph.PkgGraph) {
+	graphPath := filepath.Join(t.TempDir(), "graph.dot")
+	assert.NoError(t, pkggraph.WriteDOTGraphFile(g, graphPath))
+
+	reloaded, err := pkggraph.ReadDOTGraphFile(graphPath)
+	assert.NoError(t, err)
+
+	return
+}
+
+// findTestNode returns the node of g with the same type, SRPM and package as node.
+func findTestNode(t *testing.T, g *pkggraph.PkgGraph, node *pkggraph.PkgNode) *pkggraph.PkgNode {
+	found, err := newSnapshotNodeIndex(g).find(newNodeSnapshot(node))
+	assert.NoError(t, err)
+
+	return found
+}
+
+func TestSaveAndLoadGraphBuildState(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+	buildState.NodeBuildResult(buildNodes["built"]).Duration = time.Minute
+	buildState.NodeBuildResult(buildNodes["built"]).RebuiltDep = buildNodes["available"]
+
+	// Leave a gap in the node IDs, so they change when the graph is reloaded.
+	for _, node := range g.AllRunNodes() {
+		if node.SrpmPath == buildNodes["built"].SrpmPath {
+			g.RemovePkgNode(node)
+		}
+	}
+
+	stateDir := t.TempDir()
+	statePath := filepath.Join(stateDir, "state.json")
+	assert.NoError(t, SaveGraphBuildState(buildState, statePath))
+
+	reloadedGraph := reloadTestGraph(t, g)
+	loadedState, err := LoadGraphBuildState(reloadedGraph, statePath)
+	assert.NoError(t, err)
+
+	reloadedBuilt := findTestNode(t, reloadedGraph, buildNodes["built"])
+	assert.Equal(t, "build failed", loadedState.BuildFailures()[0].Err.Error())
+	assert.Equal(t, buildNodes["failed"].SrpmPath, loadedState.BuildFailures()[0].Node.SrpmPath)
+	assert.Equal(t, time.Minute, loadedState.NodeBuildDuration(reloadedBuilt))
+	assert.Equal(t, findTestNode(t, reloadedGraph, buildNodes["available"]), loadedState.NodeBuildResult(reloadedBuilt).RebuiltDep)
+
+	originalCSV := filepath.Join(stateDir, "original.csv")
+	loadedCSV := filepath.Join(stateDir, "loaded.csv")
+	RecordBuildSummary(g, &sync.RWMutex{}, buildState, originalCSV, SummaryCSVOptions{})
+	RecordBuildSummary(reloadedGraph, &sync.RWMutex{}, loadedState, loadedCSV, SummaryCSVOptions{})
+	originalContents, err := os.ReadFile(originalCSV)
+	assert.NoError(t, err)
+	loadedContents, err := os.ReadFile(loadedCSV)
+	assert.NoError(t, err)
+	assert.Equal(t, string(originalContents), string(loadedContents))
+}
+
+func TestLoadGraphBuildStateMissingNode(t *testing.T) {
+	_, buildState, _ := buildTestSummaryGraph(t)
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	assert.NoError(t, SaveGraphBuildState(buildState, statePath))
+
+	_, err := LoadGraphBuildState(pkggraph.NewPkgGraph(), statePath)
+	assert.Error(t, err)
+}
+
+func TestLoadGraphBuildStateRejectsDifferentGraph(t *testing.T) {
+	_, buildState, _ := buildTestSummaryGraph(t)
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	assert.NoError(t, SaveGraphBuildState(buildState, statePath))
+
+	// A graph with the same number of nodes but other packages must not match the build state.
+	otherGraph := pkggraph.NewPkgGraph()
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		addTestPackage(t, otherGraph, name)
+	}
+
+	_, err := LoadGraphBuildState(otherGraph, statePath)
+	assert.Error(t, err)
+}
@@ -0,0 +1,108 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"sync"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+)
+
+// BuildSeverity represents the overall health of a finished build.
+type BuildSeverity int
+
+// Valid values for BuildSeverity type
+const (
+	SeveritySuccess BuildSeverity = iota // All packages are available and there is nothing to report
+	SeverityWarning BuildSeverity = iota // All packages are available, but there are unresolved dependencies or ignored toolchain conflicts
+	SeverityError   BuildSeverity = iota // Some packages failed or were blocked, toolchain packages were rebuilt when not allowed, or dependencies are unresolved in strict mode
+)
+
+func (s BuildSeverity) String() string {
+	switch s {
+	case SeveritySuccess:
+		return "Success"
+	case SeverityWarning:
+		return "Warning"
+	case SeverityError:
+		return "Error"
+	default:
+		return "Unknown"
+	}
+}
+
+// BuildStatus is a machine-readable summary of the outcome of a build.
+type BuildStatus struct {
+	BuiltCount            int
+	AlreadyAvailableCount int
+	PrebuiltCount         int
+	PrebuiltDeltaCount    int // Delta SRPMs whose delta RPMs from a repo were applied instead of building them
+	DeltaSkippedCount     int // Delta SRPMs which were built instead of applying their delta RPMs, these are also counted as built
+	SkippedCount          int
+	FailedCount           int
+	TimedOutCount         int // Failed SRPMs whose build exceeded the timeout, these are also counted as failures
+	TestFailedCount       int // Built SRPMs whose %check section failed, these are not counted as failures
+	NoRPMsBuiltCount      int // Built SRPMs which did not produce any RPMs, these are not counted as failures
+	BuiltRPMCount         int // RPMs produced by the built SRPMs
+	BlockedCount          int
+	UnresolvedCount       int
+	RPMConflictCount      int
+	SRPMConflictCount     int
+
+	HasFailures   bool
+	HasUnresolved bool
+	HasConflicts  bool
+	// HasFatalConflicts is only set if there are toolchain conflicts and toolchain rebuilds are not allowed.
+	HasFatalConflicts bool
+	// HasFatalUnresolved is only set if there are unresolved dependencies and they are treated as failures.
+	HasFatalUnresolved bool
+
+	Severity BuildSeverity
+}
+
+// CalculateBuildStatus returns the overall status of a build.
+// - allowToolchainRebuilds controls if toolchain conflicts are considered fatal.
+// - strictUnresolved treats unresolved dependencies as build failures instead of warnings.
+func CalculateBuildStatus(pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, buildState *GraphBuildState, allowToolchainRebuilds, strictUnresolved bool) (status *BuildStatus) {
+	categories := GetBuildSummary(pkgGraph, graphMutex, buildState)
+	return buildStatusFromCategories(categories, buildState, allowToolchainRebuilds, strictUnresolved)
+}
+
+// buildStatusFromCategories calculates the build status from already categorized build nodes.
+func buildStatusFromCategories(categories *BuildNodeCategories, buildState *GraphBuildState, allowToolchainRebuilds, strictUnresolved bool) (status *BuildStatus) {
+	status = &BuildStatus{
+		BuiltCount:            len(categories.Built),
+		AlreadyAvailableCount: len(categories.AlreadyAvailable),
+		PrebuiltCount:         len(categories.Prebuilt),
+		PrebuiltDeltaCount:    len(categories.PrebuiltDelta),
+		SkippedCount:          len(categories.Skipped),
+		FailedCount:           len(categories.Failures),
+		TimedOutCount:         len(timedOutBuilds(categories.Failures)),
+		TestFailedCount:       len(nodesWithFailureType(categories.Built, buildState, FailureTest)),
+		NoRPMsBuiltCount:      len(builtWithoutRPMs(categories.Built, buildState)),
+		BuiltRPMCount:         builtRPMCount(categories.Built, buildState),
+		DeltaSkippedCount:     len(deltaSkippedNodes(categories.Built, buildState)),
+		BlockedCount:          len(categories.Unbuilt),
+		UnresolvedCount:       len(categories.Unresolved),
+		RPMConflictCount:      len(buildState.ConflictingRPMs()),
+		SRPMConflictCount:     len(buildState.ConflictingSRPMs()),
+	}
+
+	status.HasFailures = status.FailedCount > 0
+	status.HasUnresolved = status.UnresolvedCount > 0
+	status.HasConflicts = status.RPMConflictCount > 0 || status.SRPMConflictCount > 0
+	status.HasFatalConflicts = status.HasConflicts && !allowToolchainRebuilds
+	status.HasFatalUnresolved = status.HasUnresolved && strictUnresolved
+
+	switch {
+	case status.HasFailures, status.BlockedCount > 0, status.HasFatalConflicts, status.HasFatalUnresolved:
+		status.Severity = SeverityError
+	case status.HasUnresolved, status.HasConflicts:
+		status.Severity = SeverityWarning
+	default:
+		status.Severity = SeveritySuccess
+	}
+
+	return
+}
@@ -0,0 +1,115 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+	"github.com/stretchr/testify/assert"
+)
+
+// The scheduler's exit code is selected from these results: failed or blocked SRPMs and fatal unresolved dependencies
+// take precedence over fatal toolchain conflicts.
+func TestBuildStatusSeverity(t *testing.T) {
+	tests := []struct {
+		name                   string
+		failed                 bool
+		blocked                bool
+		unresolved             bool
+		toolchainConflict      bool
+		allowToolchainRebuilds bool
+		strictUnresolved       bool
+
+		expectedSeverity           BuildSeverity
+		expectedFatalConflicts     bool
+		expectedFatalUnresolved    bool
+		expectedPackageFailureExit bool
+	}{
+		{name: "success", expectedSeverity: SeveritySuccess},
+		{name: "success with strict unresolved", strictUnresolved: true, expectedSeverity: SeveritySuccess},
+		{name: "success with toolchain rebuilds allowed", allowToolchainRebuilds: true, expectedSeverity: SeveritySuccess},
+		{name: "unresolved", unresolved: true, expectedSeverity: SeverityWarning},
+		{name: "strict unresolved", unresolved: true, strictUnresolved: true, expectedSeverity: SeverityError, expectedFatalUnresolved: true, expectedPackageFailureExit: true},
+		{name: "toolchain conflict", toolchainConflict: true, expectedSeverity: SeverityError, expectedFatalConflicts: true},
+		{name: "allowed toolchain conflict", toolchainConflict: true, allowToolchainRebuilds: true, expectedSeverity: SeverityWarning},
+		{name: "unresolved and allowed toolchain conflict", unresolved: true, toolchainConflict: true, allowToolchainRebuilds: true, expectedSeverity: SeverityWarning},
+		{name: "failed", failed: true, expectedSeverity: SeverityError, expectedPackageFailureExit: true},
+		{name: "blocked", blocked: true, expectedSeverity: SeverityError, expectedPackageFailureExit: true},
+		{name: "failed and toolchain conflict", failed: true, toolchainConflict: true, expectedSeverity: SeverityError, expectedFatalConflicts: true, expectedPackageFailureExit: true},
+		{name: "failed and allowed toolchain conflict", failed: true, toolchainConflict: true, allowToolchainRebuilds: true, expectedSeverity: SeverityError, expectedPackageFailureExit: true},
+		{name: "strict unresolved and toolchain conflict", unresolved: true, strictUnresolved: true, toolchainConflict: true, expectedSeverity: SeverityError, expectedFatalConflicts: true, expectedFatalUnresolved: true, expectedPackageFailureExit: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := pkggraph.NewPkgGraph()
+			buildState := NewGraphBuildState([]string{"toolchain-1.0-1.cm2.x86_64.rpm"})
+			categories := &BuildNodeCategories{
+				Built:      make(map[string]*pkggraph.PkgNode),
+				Failed:     make(map[string]*pkggraph.PkgNode),
+				Unbuilt:    make(map[string]*pkggraph.PkgNode),
+				Unresolved: make(map[string]bool),
+			}
+
+			_, builtNode := addTestPackage(t, g, "toolchain")
+			builtFile := "/mariner/out/RPMS/x86_64/built-1.0-1.cm2.x86_64.rpm"
+			if test.toolchainConflict {
+				builtFile = builtNode.RpmPath
+			}
+			buildState.RecordBuildResult(&BuildResult{Node: builtNode, AncillaryNodes: []*pkggraph.PkgNode{builtNode}, Attempts: 1, BuiltFiles: []string{builtFile}})
+			categories.Built[builtNode.SrpmPath] = builtNode
+
+			if test.failed {
+				_, failedNode := addTestPackage(t, g, "failed")
+				recordTestResult(buildState, failedNode, false, false, fmt.Errorf("exit status 1"))
+				categories.Failed[failedNode.SrpmPath] = failedNode
+				categories.Failures = buildState.BuildFailures()
+			}
+			if test.blocked {
+				_, blockedNode := addTestPackage(t, g, "blocked")
+				categories.Unbuilt[blockedNode.SrpmPath] = blockedNode
+			}
+			if test.unresolved {
+				categories.Unresolved["missing"] = true
+			}
+
+			status := buildStatusFromCategories(categories, buildState, test.allowToolchainRebuilds, test.strictUnresolved)
+			assert.Equal(t, test.expectedSeverity, status.Severity)
+			assert.Equal(t, test.toolchainConflict, status.HasConflicts)
+			assert.Equal(t, test.expectedFatalConflicts, status.HasFatalConflicts)
+			assert.Equal(t, test.expectedFatalUnresolved, status.HasFatalUnresolved)
+			assert.Equal(t, test.expectedPackageFailureExit, status.HasFailures || status.BlockedCount > 0 || status.HasFatalUnresolved)
+		})
+	}
+}
+
+func TestBuildSeverityOrdering(t *testing.T) {
+	assert.Less(t, SeveritySuccess, SeverityWarning)
+	assert.Less(t, SeverityWarning, SeverityError)
+	assert.Equal(t, []string{"Success", "Warning", "Error", "Unknown"}, []string{SeveritySuccess.String(), SeverityWarning.String(), SeverityError.String(), BuildSeverity(-1).String()})
+}
+
+func TestStrictUnresolvedTreatsUnresolvedDependenciesAsFailures(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+
+	status := CalculateBuildStatus(g, &sync.RWMutex{}, buildState, false, false)
+	assert.True(t, status.HasUnresolved)
+	assert.False(t, status.HasFatalUnresolved)
+
+	status = CalculateBuildStatus(g, &sync.RWMutex{}, buildState, false, true)
+	assert.True(t, status.HasFatalUnresolved)
+	assert.Equal(t, SeverityError, status.Severity)
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+	assert.Contains(t, output.String(), "Unresolved dependencies:\n--> missing")
+
+	output.Reset()
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{StrictUnresolved: true})
+	assert.Contains(t, output.String(), "Unresolved dependencies (i.e., treated as build failures):\n--> missing")
+}
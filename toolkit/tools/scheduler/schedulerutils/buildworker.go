@@ -5,9 +5,11 @@ package schedulerutils
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -23,6 +25,32 @@ import (
 	"gonum.org/v1/gonum/graph/traverse"
 )
 
+// FailureType describes which stage of a package build failed.
+type FailureType int
+
+// Valid values for FailureType type
+const (
+	FailureNone    FailureType = iota // The package built successfully
+	FailureBuild   FailureType = iota // rpmbuild failed to build the package
+	FailureTest    FailureType = iota // The package built, but its %check section failed
+	FailureInstall FailureType = iota // The package's build dependencies could not be installed
+)
+
+func (f FailureType) String() string {
+	switch f {
+	case FailureNone:
+		return "None"
+	case FailureBuild:
+		return "Build"
+	case FailureTest:
+		return "Test"
+	case FailureInstall:
+		return "Install"
+	default:
+		return "Unknown"
+	}
+}
+
 // BuildChannels represents the communicate channels used by a build agent.
 type BuildChannels struct {
 	Requests         <-chan *BuildRequest
@@ -34,23 +62,42 @@ type BuildChannels struct {
 
 // BuildRequest represents the results of a build agent trying to build a given node.
 type BuildRequest struct {
-	Node           *pkggraph.PkgNode
-	PkgGraph       *pkggraph.PkgGraph
-	AncillaryNodes []*pkggraph.PkgNode
-	CanUseCache    bool
-	IsDelta        bool
+	Node            *pkggraph.PkgNode
+	PkgGraph        *pkggraph.PkgGraph
+	AncillaryNodes  []*pkggraph.PkgNode
+	CanUseCache     bool
+	CacheMissReason CacheMissReason   // Why the cache can't be used, only set if CanUseCache is false
+	RebuiltDep      *pkggraph.PkgNode // The dependency which was rebuilt, only set if CacheMissReason is CacheMissDependencyRebuilt
+	IsDelta         bool
+	QueuedTime      time.Time // Time the request was created, once the node became unblocked
 }
 
 // BuildResult represents the results of a build agent trying to build a given node.
 type BuildResult struct {
-	AncillaryNodes []*pkggraph.PkgNode
-	BuiltFiles     []string
-	Err            error
-	LogFile        string
-	Node           *pkggraph.PkgNode
-	Skipped        bool
-	UsedCache      bool
-	WasDelta       bool
+	AncillaryNodes  []*pkggraph.PkgNode
+	Attempts        int // Number of times the SRPM was sent to the build agent, zero if the node was not built
+	BuiltFiles      []string
+	CacheAvailable  bool            // All of the SRPM's cached RPMs were found, set even if the SRPM was rebuilt regardless
+	CacheMayBeStale bool            // The node's spec has a newer %changelog entry than the cached delta RPMs
+	CacheMissReason CacheMissReason // Why the SRPM was built instead of using the cache, CacheMissNone if it was not built
+	CacheSource     string          // Repo the cached RPMs were downloaded from, or the directory they were found in if unknown. Only set if UsedCache
+	Cores           int             // Number of cores rpmbuild was allowed to use for parallel jobs, zero if not capped or the node was not built
+	Duration        time.Duration   // Time spent building the SRPM, zero if the node was not built
+	EndTime         time.Time       // Time the build of the SRPM finished, zero if the node was not built
+	Err             error
+	FailureLine     int         // Line of LogFile where the failure was reported, zero if it could not be found
+	FailureMessage  string      // Failure reported on FailureLine, starting at the install or rpmbuild failure message
+	FailureType     FailureType // Which stage of the build failed, tests may fail without setting Err
+	LogFile         string
+	Node            *pkggraph.PkgNode
+	PeakRSS         int64             // Peak resident memory of the build in bytes, zero if unknown or the node was not built
+	QueuedTime      time.Time         // Time the build request was queued for a worker
+	RebuiltDep      *pkggraph.PkgNode // The dependency which was rebuilt, only set if CacheMissReason is CacheMissDependencyRebuilt
+	Skipped         bool
+	StartTime       time.Time // Time the build of the SRPM started, zero if the node was not built
+	TimedOut        bool      // The build agent stopped the build after it exceeded the per-package timeout
+	UsedCache       bool
+	WasDelta        bool
 }
 
 // selectNextBuildRequest selects a job based on priority:
@@ -101,12 +148,40 @@ func BuildNodeWorker(channels *BuildChannels, agent buildagents.BuildAgent, grap
 		res := &BuildResult{
 			Node:           req.Node,
 			AncillaryNodes: req.AncillaryNodes,
+			QueuedTime:     req.QueuedTime,
 			WasDelta:       req.IsDelta,
 		}
 
 		switch req.Node.Type {
 		case pkggraph.TypeLocalBuild:
-			res.UsedCache, res.Skipped, res.BuiltFiles, res.LogFile, res.Err = buildBuildNode(req.Node, req.PkgGraph, graphMutex, agent, req.CanUseCache, buildAttempts, checkAttempts, ignoredPackages)
+			buildStart := time.Now()
+			res.UsedCache, res.Skipped, res.CacheAvailable, res.BuiltFiles, res.LogFile, res.Attempts, res.PeakRSS, res.FailureType, res.Err = buildBuildNode(req.Node, req.PkgGraph, graphMutex, agent, req.CanUseCache, buildAttempts, checkAttempts, ignoredPackages)
+			res.TimedOut = errors.Is(res.Err, buildagents.ErrBuildTimedOut)
+			if res.Err != nil && res.LogFile != "" {
+				res.FailureLine, res.FailureMessage = parseFailureLine(res.LogFile)
+			}
+			if !res.UsedCache && !res.Skipped {
+				res.StartTime, res.EndTime = buildStart, time.Now()
+				res.Duration = res.EndTime.Sub(buildStart)
+				res.Cores = allocatedCores(agent.Config().MaxCpu)
+				res.CacheMissReason = req.CacheMissReason
+				res.RebuiltDep = req.RebuiltDep
+				// The scheduler allowed using the cache, but the worker didn't find all of the cached RPMs.
+				if req.CanUseCache {
+					res.CacheMissReason = CacheMissRPMsAbsent
+					res.RebuiltDep = nil
+				}
+			}
+			if res.UsedCache {
+				res.CacheSource = cacheSource(req, res.BuiltFiles)
+			}
+			if res.UsedCache && res.WasDelta && len(res.BuiltFiles) != 0 {
+				stale, err := isCachedRPMStale(req.Node, res.BuiltFiles[0])
+				if err != nil {
+					logger.Log.Debugf("Unable to check if the cached RPMs of '%s' are stale. Error: %s", req.Node.SRPMFileName(), err)
+				}
+				res.CacheMayBeStale = stale
+			}
 			if res.Err == nil {
 				setAncillaryBuildNodesStatus(req, pkggraph.StateUpToDate)
 			} else {
@@ -129,11 +204,12 @@ func BuildNodeWorker(channels *BuildChannels, agent buildagents.BuildAgent, grap
 }
 
 // buildBuildNode builds a TypeBuild node, either used a cached copy if possible or building the corresponding SRPM.
-func buildBuildNode(node *pkggraph.PkgNode, pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, agent buildagents.BuildAgent, canUseCache bool, buildAttempts int, checkAttempts int, ignoredPackages []*pkgjson.PackageVer) (usedCache, skipped bool, builtFiles []string, logFile string, err error) {
+func buildBuildNode(node *pkggraph.PkgNode, pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, agent buildagents.BuildAgent, canUseCache bool, buildAttempts int, checkAttempts int, ignoredPackages []*pkgjson.PackageVer) (usedCache, skipped, cacheAvailable bool, builtFiles []string, logFile string, attempts int, peakRSS int64, failureType FailureType, err error) {
 	var missingFiles []string
 
 	baseSrpmName := node.SRPMFileName()
 	usedCache, builtFiles, missingFiles = pkggraph.IsSRPMPrebuilt(node.SrpmPath, pkgGraph, graphMutex)
+	cacheAvailable = usedCache
 	skipped = sliceutils.Contains(ignoredPackages, node.VersionedPkg, sliceutils.PackageVerMatch)
 
 	if skipped {
@@ -156,10 +232,26 @@ func buildBuildNode(node *pkggraph.PkgNode, pkgGraph *pkggraph.PkgGraph, graphMu
 	dependencies := getBuildDependencies(node, pkgGraph, graphMutex)
 
 	logger.Log.Infof("Building %s", baseSrpmName)
-	builtFiles, logFile, err = buildSRPMFile(agent, buildAttempts, checkAttempts, node.SrpmPath, node.Architecture, dependencies)
+	builtFiles, logFile, attempts, peakRSS, failureType, err = buildSRPMFile(agent, buildAttempts, checkAttempts, node.SrpmPath, node.Architecture, dependencies)
 	return
 }
 
+// cacheSource returns where the cached RPMs of a build request came from: the repo recorded on its nodes when their
+// delta RPMs were downloaded, otherwise the directory the cached RPMs were found in.
+func cacheSource(req *BuildRequest, cachedFiles []string) string {
+	for _, node := range req.AncillaryNodes {
+		if isRemoteSourceRepo(node.SourceRepo) {
+			return node.SourceRepo
+		}
+	}
+
+	if len(cachedFiles) != 0 {
+		return filepath.Dir(cachedFiles[0])
+	}
+
+	return ""
+}
+
 // getBuildDependencies returns a list of all dependencies that need to be installed before the node can be built.
 func getBuildDependencies(node *pkggraph.PkgNode, pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex) (dependencies []string) {
 	graphMutex.RLock()
@@ -225,7 +317,8 @@ func parseCheckSection(logFile string) (err error) {
 }
 
 // buildSRPMFile sends an SRPM to a build agent to build.
-func buildSRPMFile(agent buildagents.BuildAgent, buildAttempts int, checkAttempts int, srpmFile, outArch string, dependencies []string) (builtFiles []string, logFile string, err error) {
+// peakRSS is the highest peak resident memory reported across all attempts, zero if unknown.
+func buildSRPMFile(agent buildagents.BuildAgent, buildAttempts int, checkAttempts int, srpmFile, outArch string, dependencies []string) (builtFiles []string, logFile string, attempts int, peakRSS int64, failureType FailureType, err error) {
 	const (
 		retryDuration = time.Second
 	)
@@ -241,7 +334,12 @@ func buildSRPMFile(agent buildagents.BuildAgent, buildAttempts int, checkAttempt
 	}
 
 	err = retry.Run(func() (buildErr error) {
-		builtFiles, logFile, buildErr = agent.BuildPackage(srpmFile, logBaseName, outArch, dependencies)
+		var attemptPeakRSS int64
+		attempts++
+		builtFiles, logFile, attemptPeakRSS, buildErr = agent.BuildPackage(srpmFile, logBaseName, outArch, dependencies)
+		if attemptPeakRSS > peakRSS {
+			peakRSS = attemptPeakRSS
+		}
 		// If the package builds with no errors and RUN_CHECK=y, check logs to see if the %check section passed, and if not, return as the build error.
 		if buildErr != nil {
 			return
@@ -257,8 +355,95 @@ func buildSRPMFile(agent buildagents.BuildAgent, buildAttempts int, checkAttempt
 	// temporary solution; potential fix: once stable, fail builds if %check section fails?
 	if err != nil && checkFailed {
 		logger.Log.Warnf("Tests failed for '%s'. Ignoring since the package built correctly. Error: %v", srpmFile, err)
+		failureType = FailureTest
 		err = nil
+	} else if err != nil {
+		failureType = parseFailureType(logFile)
+	}
+	return
+}
+
+// installFailureMessages are logged by pkgworker if tdnf fails to install the build requirements.
+var installFailureMessages = []string{
+	"Failed to install build requirements",
+	"unable to install the following packages",
+}
+
+// buildFailureMessages are logged by rpmbuild when a stage of the build fails.
+var buildFailureMessages = []string{
+	"error: Bad exit status from",
+	"RPM build errors:",
+}
+
+// parseFailureType reads the package build log file to determine if the build failed while installing
+// the build dependencies or while building the package itself.
+func parseFailureType(logFile string) (failureType FailureType) {
+	failureType = FailureBuild
+
+	logFileObject, err := os.Open(logFile)
+	if err != nil {
+		logger.Log.Debugf("Failed to open log file '%s' while checking the build failure type. Error: %v", logFile, err)
+		return
 	}
+	defer logFileObject.Close()
+
+	for scanner := bufio.NewScanner(logFileObject); scanner.Scan(); {
+		currLine := scanner.Text()
+		for _, message := range installFailureMessages {
+			if strings.Contains(currLine, message) {
+				return FailureInstall
+			}
+		}
+	}
+	return
+}
+
+// parseFailureLine reads the package build log file and returns the 1-based number of the first line reporting
+// an install or rpmbuild failure, along with the failure it reports: the rest of the line starting at the failure
+// message, without the log entry's prefix. e.g. "error: Bad exit status from /var/tmp/rpm-tmp.Xn1x2 (%check)".
+// Returns zero and an empty message if the log can't be read or no failure message is found.
+func parseFailureLine(logFile string) (failureLine int, failureMessage string) {
+	// Log entries written to the file are quoted, e.g. time="..." level=debug msg="error: ...".
+	const logEntryQuote = `"`
+
+	logFileObject, err := os.Open(logFile)
+	if err != nil {
+		logger.Log.Debugf("Failed to open log file '%s' while looking for the failure line. Error: %v", logFile, err)
+		return
+	}
+	defer logFileObject.Close()
+
+	failureMessages := append(append([]string{}, installFailureMessages...), buildFailureMessages...)
+
+	lineNumber := 0
+	for scanner := bufio.NewScanner(logFileObject); scanner.Scan(); {
+		lineNumber++
+		currLine := scanner.Text()
+		for _, message := range failureMessages {
+			if messageStart := strings.Index(currLine, message); messageStart >= 0 {
+				failureLine = lineNumber
+				failureMessage = strings.TrimSpace(strings.TrimSuffix(currLine[messageStart:], logEntryQuote))
+				return
+			}
+		}
+	}
+	return
+}
+
+// allocatedCores returns the number of cores rpmbuild was allowed to use for parallel jobs, as passed to the agent's
+// MaxCpu setting (%_smp_ncpus_max). Returns zero if it is not set or can't be parsed: rpmbuild then uses every CPU of
+// the machine running the build, which the scheduler doesn't know.
+func allocatedCores(maxCPU string) (cores int) {
+	if maxCPU == "" {
+		return
+	}
+
+	cores, err := strconv.Atoi(maxCPU)
+	if err != nil || cores <= 0 {
+		logger.Log.Debugf("Unable to parse the max CPU setting '%s', not recording the allocated cores", maxCPU)
+		return 0
+	}
+
 	return
 }
 
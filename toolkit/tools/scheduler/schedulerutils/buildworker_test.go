@@ -0,0 +1,85 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheSourceUsesDownloadRepo(t *testing.T) {
+	req := &BuildRequest{AncillaryNodes: []*pkggraph.PkgNode{
+		{SourceRepo: "<LOCAL>"},
+		{SourceRepo: "mariner-official-base"},
+	}}
+
+	assert.Equal(t, "mariner-official-base", cacheSource(req, []string{"/cache/zlib-1.2.13-1.cm2.x86_64.rpm"}))
+}
+
+func TestCacheSourceFallsBackToRPMDirectory(t *testing.T) {
+	req := &BuildRequest{AncillaryNodes: []*pkggraph.PkgNode{
+		{SourceRepo: "<LOCAL>"},
+		{SourceRepo: "<NO_REPO>"},
+	}}
+
+	assert.Equal(t, "/out/RPMS/x86_64", cacheSource(req, []string{"/out/RPMS/x86_64/zlib-1.2.13-1.cm2.x86_64.rpm"}))
+	assert.Empty(t, cacheSource(req, nil))
+}
+
+func TestParseFailureLine(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "build.log")
+	assert.NoError(t, os.WriteFile(logFile, []byte("Building\n+ make\nmake: *** [all] Error 1\nerror: Bad exit status from /var/tmp/rpm-tmp.1234 (%build)\nRPM build errors:\n"), 0644))
+	failureLine, failureMessage := parseFailureLine(logFile)
+	assert.Equal(t, 4, failureLine)
+	assert.Equal(t, "error: Bad exit status from /var/tmp/rpm-tmp.1234 (%build)", failureMessage)
+
+	assert.NoError(t, os.WriteFile(logFile, []byte(`time="2023-04-27T10:15:02Z" level=debug msg="error: Bad exit status from /var/tmp/rpm-tmp.1234 (%check)"`+"\n"), 0644))
+	failureLine, failureMessage = parseFailureLine(logFile)
+	assert.Equal(t, 1, failureLine)
+	assert.Equal(t, "error: Bad exit status from /var/tmp/rpm-tmp.1234 (%check)", failureMessage)
+
+	assert.NoError(t, os.WriteFile(logFile, []byte("Building\nDone\n"), 0644))
+	failureLine, failureMessage = parseFailureLine(logFile)
+	assert.Equal(t, 0, failureLine)
+	assert.Empty(t, failureMessage)
+
+	failureLine, failureMessage = parseFailureLine(filepath.Join(t.TempDir(), "missing.log"))
+	assert.Equal(t, 0, failureLine)
+	assert.Empty(t, failureMessage)
+}
+
+func TestAllocatedCores(t *testing.T) {
+	assert.Zero(t, allocatedCores(""))
+	assert.Zero(t, allocatedCores("not-a-number"))
+	assert.Zero(t, allocatedCores("0"))
+	assert.Equal(t, 1, allocatedCores("1"))
+	assert.Equal(t, 256, allocatedCores("256"))
+}
+
+func TestBuildSummaryReportsAllocatedCores(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+	buildState.NodeBuildResult(buildNodes["built"]).Cores = 8
+	buildState.NodeBuildResult(buildNodes["failed"]).Cores = 3
+
+	average, builds := averageAllocatedCores(CategorizeBuildNodes(g, buildState), buildState)
+	assert.Equal(t, 5.5, average)
+	assert.Equal(t, 2, builds)
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+	assert.Contains(t, output.String(), "Average cores allocated per build: 5.5 (over 2 builds)\n")
+
+	outputPath := filepath.Join(t.TempDir(), "summary.csv")
+	RecordBuildSummary(g, &sync.RWMutex{}, buildState, outputPath, SummaryCSVOptions{Columns: []string{"Package", "Cores"}})
+	contents, err := os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(contents), "\nbuilt-1.0-1.cm2.src.rpm,8\n")
+	assert.Contains(t, string(contents), "\ncached-1.0-1.cm2.src.rpm,\n")
+}
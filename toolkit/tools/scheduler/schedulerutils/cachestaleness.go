@@ -0,0 +1,189 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+)
+
+const (
+	// rpmLeadSize is the size of the legacy lead at the start of every RPM file.
+	rpmLeadSize = 96
+	// rpmChangelogTimeTag is the header tag holding the time of each %changelog entry, newest first.
+	rpmChangelogTimeTag = 1080
+	// rpmInt32Type is the header type of 32-bit integer values.
+	rpmInt32Type = 4
+)
+
+// rpmHeaderMagic starts both the signature header and the main header of an RPM file.
+var rpmHeaderMagic = []byte{0x8e, 0xad, 0xe8, 0x01}
+
+// rpmHeaderEntry is an entry of an RPM header's index, locating the value of a tag in the header's data store.
+type rpmHeaderEntry struct {
+	Tag    int32
+	Type   int32
+	Offset int32
+	Count  int32
+}
+
+// isCachedRPMStale checks if the newest %changelog entry of the node's spec is more recent than the newest entry of
+// the cached RPM, in which case the spec was changed since the cached RPM was built without bumping its release.
+// The changelog is compared rather than file times, since checking out or touching the spec doesn't change it.
+// A spec or RPM without a changelog is never reported as stale.
+func isCachedRPMStale(node *pkggraph.PkgNode, cachedRPM string) (isStale bool, err error) {
+	specChangelogTime, found, err := latestSpecChangelogTime(node.SpecPath)
+	if err != nil || !found {
+		return
+	}
+
+	rpmChangelogTime, found, err := latestRPMChangelogTime(cachedRPM)
+	if err != nil || !found {
+		return
+	}
+
+	// Changelog entries only record a date, rpm stores it as noon UTC of that day.
+	rpmChangelogDay := rpmChangelogTime.UTC().Truncate(24 * time.Hour)
+	isStale = specChangelogTime.After(rpmChangelogDay)
+	return
+}
+
+// latestSpecChangelogTime returns the date of the first, newest, entry of a spec's %changelog section.
+// e.g. "* Thu Apr 27 2023 Packager <packager@example.com> - 1.2.13-1" -> 2023-04-27 00:00:00 UTC
+func latestSpecChangelogTime(specPath string) (changelogTime time.Time, found bool, err error) {
+	const (
+		changelogSection     = "%changelog"
+		changelogEntryPrefix = "*"
+		changelogDateLayout  = "Mon Jan 2 2006"
+		changelogDateFields  = 4
+	)
+
+	specFile, err := os.Open(specPath)
+	if err != nil {
+		return
+	}
+	defer specFile.Close()
+
+	inChangelog := false
+	for scanner := bufio.NewScanner(specFile); scanner.Scan(); {
+		line := strings.TrimSpace(scanner.Text())
+		if !inChangelog {
+			inChangelog = strings.EqualFold(line, changelogSection)
+			continue
+		}
+
+		if !strings.HasPrefix(line, changelogEntryPrefix) {
+			continue
+		}
+
+		fields := strings.Fields(strings.TrimPrefix(line, changelogEntryPrefix))
+		if len(fields) < changelogDateFields {
+			err = fmt.Errorf("malformed changelog entry '%s' in '%s'", line, specPath)
+			return
+		}
+
+		changelogTime, err = time.Parse(changelogDateLayout, strings.Join(fields[:changelogDateFields], " "))
+		found = err == nil
+		return
+	}
+
+	return
+}
+
+// latestRPMChangelogTime returns the time of the first, newest, %changelog entry recorded in an RPM's header.
+// The header is read directly instead of running rpm, since this is checked for every build restored from delta RPMs.
+func latestRPMChangelogTime(rpmPath string) (changelogTime time.Time, found bool, err error) {
+	const signatureAlignment = 8
+
+	rpmFile, err := os.Open(rpmPath)
+	if err != nil {
+		return
+	}
+	defer rpmFile.Close()
+
+	reader := bufio.NewReader(rpmFile)
+	_, err = reader.Discard(rpmLeadSize)
+	if err != nil {
+		err = fmt.Errorf("failed to read the lead of '%s':\n%w", rpmPath, err)
+		return
+	}
+
+	// The signature header is padded so the main header starts on an 8 byte boundary.
+	_, signatureStore, err := readRPMHeader(reader)
+	if err != nil {
+		err = fmt.Errorf("failed to read the signature header of '%s':\n%w", rpmPath, err)
+		return
+	}
+	_, err = reader.Discard((signatureAlignment - len(signatureStore)%signatureAlignment) % signatureAlignment)
+	if err != nil {
+		err = fmt.Errorf("failed to read the signature header of '%s':\n%w", rpmPath, err)
+		return
+	}
+
+	entries, store, err := readRPMHeader(reader)
+	if err != nil {
+		err = fmt.Errorf("failed to read the header of '%s':\n%w", rpmPath, err)
+		return
+	}
+
+	for _, entry := range entries {
+		if entry.Tag != rpmChangelogTimeTag || entry.Type != rpmInt32Type || entry.Count == 0 {
+			continue
+		}
+
+		if entry.Offset < 0 || int(entry.Offset)+binary.Size(int32(0)) > len(store) {
+			err = fmt.Errorf("changelog time of '%s' is outside of its header", rpmPath)
+			return
+		}
+
+		changelogTime = time.Unix(int64(binary.BigEndian.Uint32(store[entry.Offset:])), 0)
+		found = true
+		return
+	}
+
+	return
+}
+
+// readRPMHeader reads an RPM header structure: its magic, index and data store.
+// The index entries and data store are returned as-is, offsets in the entries are relative to the store.
+func readRPMHeader(reader io.Reader) (entries []rpmHeaderEntry, store []byte, err error) {
+	var intro struct {
+		Magic      [4]byte
+		Reserved   [4]byte
+		NumEntries int32
+		StoreSize  int32
+	}
+
+	err = binary.Read(reader, binary.BigEndian, &intro)
+	if err != nil {
+		return
+	}
+
+	if string(intro.Magic[:]) != string(rpmHeaderMagic) {
+		err = fmt.Errorf("invalid header magic %x", intro.Magic)
+		return
+	}
+
+	if intro.NumEntries < 0 || intro.StoreSize < 0 {
+		err = fmt.Errorf("invalid header size (%d entries, %d bytes)", intro.NumEntries, intro.StoreSize)
+		return
+	}
+
+	entries = make([]rpmHeaderEntry, intro.NumEntries)
+	err = binary.Read(reader, binary.BigEndian, entries)
+	if err != nil {
+		return
+	}
+
+	store = make([]byte, intro.StoreSize)
+	_, err = io.ReadFull(reader, store)
+	return
+}
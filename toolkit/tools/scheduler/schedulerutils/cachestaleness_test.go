@@ -0,0 +1,138 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSpecChangelog = `Summary:        Compression library
+Name:           delta
+Version:        1.0
+Release:        1%{?dist}
+
+%description
+Compression library.
+
+%changelog
+* Thu Apr 27 2023 Packager <packager@example.com> - 1.0-1
+- Upgrade to 1.0
+
+* Mon Jan 02 2023 Packager <packager@example.com> - 0.9-1
+- Original version
+`
+
+// writeTestRPMHeader writes an RPM file with an empty signature and a main header holding the given changelog times,
+// the way rpmbuild lays them out. No changelog tag is written if changelogTimes is empty.
+func writeTestRPMHeader(t *testing.T, rpmPath string, changelogTimes ...time.Time) {
+	writeHeader := func(buffer *bytes.Buffer, entries []rpmHeaderEntry, store []byte) {
+		buffer.Write(rpmHeaderMagic)
+		buffer.Write(make([]byte, 4))
+		assert.NoError(t, binary.Write(buffer, binary.BigEndian, []int32{int32(len(entries)), int32(len(store))}))
+		assert.NoError(t, binary.Write(buffer, binary.BigEndian, entries))
+		buffer.Write(store)
+	}
+
+	var rpmFile bytes.Buffer
+	rpmFile.Write(make([]byte, rpmLeadSize))
+
+	// A 3 byte signature store is padded to 8 bytes.
+	writeHeader(&rpmFile, nil, []byte{1, 2, 3})
+	rpmFile.Write(make([]byte, 5))
+
+	var (
+		entries []rpmHeaderEntry
+		store   bytes.Buffer
+	)
+	// Another tag ahead of the changelog, so the changelog times don't start the store.
+	const nameTag, stringType = 1000, 6
+	entries = append(entries, rpmHeaderEntry{Tag: nameTag, Type: stringType, Offset: 0, Count: 1})
+	store.WriteString("delta\x00\x00\x00")
+	if len(changelogTimes) != 0 {
+		entries = append(entries, rpmHeaderEntry{Tag: rpmChangelogTimeTag, Type: rpmInt32Type, Offset: int32(store.Len()), Count: int32(len(changelogTimes))})
+		for _, changelogTime := range changelogTimes {
+			assert.NoError(t, binary.Write(&store, binary.BigEndian, int32(changelogTime.Unix())))
+		}
+	}
+	writeHeader(&rpmFile, entries, store.Bytes())
+
+	assert.NoError(t, os.WriteFile(rpmPath, rpmFile.Bytes(), 0644))
+}
+
+func TestLatestSpecChangelogTime(t *testing.T) {
+	testDir := t.TempDir()
+	specPath := filepath.Join(testDir, "delta.spec")
+	assert.NoError(t, os.WriteFile(specPath, []byte(testSpecChangelog), 0644))
+
+	changelogTime, found, err := latestSpecChangelogTime(specPath)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, time.Date(2023, time.April, 27, 0, 0, 0, 0, time.UTC), changelogTime)
+
+	withoutChangelog := filepath.Join(testDir, "no-changelog.spec")
+	assert.NoError(t, os.WriteFile(withoutChangelog, []byte("Name: delta\n"), 0644))
+	_, found, err = latestSpecChangelogTime(withoutChangelog)
+	assert.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestLatestRPMChangelogTime(t *testing.T) {
+	testDir := t.TempDir()
+	newest := time.Date(2023, time.April, 27, 12, 0, 0, 0, time.UTC)
+
+	rpmPath := filepath.Join(testDir, "delta-1.0-1.cm2.x86_64.rpm")
+	writeTestRPMHeader(t, rpmPath, newest, newest.AddDate(0, -3, 0))
+	changelogTime, found, err := latestRPMChangelogTime(rpmPath)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.True(t, newest.Equal(changelogTime))
+
+	writeTestRPMHeader(t, rpmPath)
+	_, found, err = latestRPMChangelogTime(rpmPath)
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	notAnRPM := filepath.Join(testDir, "not-an-rpm.rpm")
+	assert.NoError(t, os.WriteFile(notAnRPM, bytes.Repeat([]byte{0}, 200), 0644))
+	_, _, err = latestRPMChangelogTime(notAnRPM)
+	assert.Error(t, err)
+}
+
+func TestIsCachedRPMStaleComparesChangelogs(t *testing.T) {
+	testDir := t.TempDir()
+	node := &pkggraph.PkgNode{SpecPath: filepath.Join(testDir, "delta.spec")}
+	assert.NoError(t, os.WriteFile(node.SpecPath, []byte(testSpecChangelog), 0644))
+	rpmPath := filepath.Join(testDir, "delta-1.0-1.cm2.x86_64.rpm")
+
+	// rpm records the spec's changelog dates at noon UTC.
+	writeTestRPMHeader(t, rpmPath, time.Date(2023, time.April, 27, 12, 0, 0, 0, time.UTC))
+	stale, err := isCachedRPMStale(node, rpmPath)
+	assert.NoError(t, err)
+	assert.False(t, stale)
+
+	// A fresh checkout or touching the spec doesn't make the cached RPM stale.
+	assert.NoError(t, os.Chtimes(node.SpecPath, time.Now(), time.Now().Add(time.Hour)))
+	stale, err = isCachedRPMStale(node, rpmPath)
+	assert.NoError(t, err)
+	assert.False(t, stale)
+
+	// The spec gained a changelog entry the cached RPM wasn't built with.
+	writeTestRPMHeader(t, rpmPath, time.Date(2023, time.January, 2, 12, 0, 0, 0, time.UTC))
+	stale, err = isCachedRPMStale(node, rpmPath)
+	assert.NoError(t, err)
+	assert.True(t, stale)
+
+	writeTestRPMHeader(t, rpmPath)
+	stale, err = isCachedRPMStale(node, rpmPath)
+	assert.NoError(t, err)
+	assert.False(t, stale)
+}
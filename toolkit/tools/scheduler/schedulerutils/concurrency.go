@@ -0,0 +1,74 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"sort"
+	"time"
+)
+
+// buildConcurrency describes how many builds were running simultaneously over the course of a build.
+type buildConcurrency struct {
+	histogram map[int]time.Duration // Time spent with exactly N builds running, from the first build's start to the last build's end
+	peak      int                   // Highest number of builds running at once
+	average   float64               // Time weighted average number of builds running
+}
+
+// concurrencyEvent is the start (+1) or end (-1) of a build.
+type concurrencyEvent struct {
+	at    time.Time
+	delta int
+}
+
+// calculateBuildConcurrency sweeps over the start and end times of the builds of this run to find how many were
+// running simultaneously. Results without a recorded start time, e.g. cached packages, are ignored.
+func calculateBuildConcurrency(categories *BuildNodeCategories, buildState *GraphBuildState) (concurrency buildConcurrency) {
+	var events []concurrencyEvent
+	addResult := func(res *BuildResult) {
+		if res != nil && !res.StartTime.IsZero() && !res.EndTime.IsZero() {
+			events = append(events, concurrencyEvent{at: res.StartTime, delta: 1}, concurrencyEvent{at: res.EndTime, delta: -1})
+		}
+	}
+
+	for _, node := range categories.Built {
+		addResult(buildState.NodeBuildResult(node))
+	}
+	for _, failure := range categories.Failures {
+		addResult(failure)
+	}
+
+	concurrency.histogram = make(map[int]time.Duration)
+	if len(events) == 0 {
+		return
+	}
+
+	// Process ends before starts at the same instant, so back to back builds on one worker don't count as concurrent.
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].at.Equal(events[j].at) {
+			return events[i].at.Before(events[j].at)
+		}
+		return events[i].delta < events[j].delta
+	})
+
+	running := 0
+	weightedTotal := 0.0
+	for i, event := range events {
+		if i > 0 {
+			elapsed := event.at.Sub(events[i-1].at)
+			concurrency.histogram[running] += elapsed
+			weightedTotal += float64(running) * elapsed.Seconds()
+		}
+
+		running += event.delta
+		if running > concurrency.peak {
+			concurrency.peak = running
+		}
+	}
+
+	if span := events[len(events)-1].at.Sub(events[0].at); span > 0 {
+		concurrency.average = weightedTotal / span.Seconds()
+	}
+
+	return
+}
@@ -0,0 +1,50 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildSummaryReportsBuildConcurrency(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+	assert.NotContains(t, output.String(), "Build concurrency")
+
+	// built runs alone for a minute, then overlaps with failed for a minute, which then runs alone for two minutes.
+	start := time.Date(2023, time.March, 1, 12, 0, 0, 0, time.UTC)
+	builtResult := buildState.NodeBuildResult(buildNodes["built"])
+	builtResult.StartTime, builtResult.EndTime = start, start.Add(2*time.Minute)
+	failedResult := buildState.NodeBuildResult(buildNodes["failed"])
+	failedResult.StartTime, failedResult.EndTime = start.Add(time.Minute), start.Add(4*time.Minute)
+
+	concurrency := calculateBuildConcurrency(CategorizeBuildNodes(g, buildState), buildState)
+	assert.Equal(t, 2, concurrency.peak)
+	assert.InDelta(t, 1.25, concurrency.average, 0.0001)
+	assert.Equal(t, map[int]time.Duration{1: 3 * time.Minute, 2: time.Minute}, concurrency.histogram)
+
+	output.Reset()
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+	assert.Contains(t, output.String(), "Build concurrency: peak 2, average 1.2\n--> 1 running: 3m0s\n--> 2 running: 1m0s\n")
+}
+
+func TestBuildConcurrencyDoesNotOverlapBackToBackBuilds(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+	start := time.Date(2023, time.March, 1, 12, 0, 0, 0, time.UTC)
+	builtResult := buildState.NodeBuildResult(buildNodes["built"])
+	builtResult.StartTime, builtResult.EndTime = start, start.Add(time.Minute)
+	failedResult := buildState.NodeBuildResult(buildNodes["failed"])
+	failedResult.StartTime, failedResult.EndTime = start.Add(time.Minute), start.Add(2*time.Minute)
+
+	concurrency := calculateBuildConcurrency(CategorizeBuildNodes(g, buildState), buildState)
+	assert.Equal(t, 1, concurrency.peak)
+	assert.Equal(t, 1.0, concurrency.average)
+}
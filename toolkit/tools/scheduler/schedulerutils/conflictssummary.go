@@ -0,0 +1,56 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"strconv"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/logger"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+)
+
+// RecordConflictsSummary stores the toolchain RPMs which were rebuilt, and the SRPMs which produced them, in to a csv.
+// Each row holds the conflicting file, the version-release it was rebuilt at, the SRPM which rebuilt it (for RPMs) and
+// whether toolchain rebuilds were allowed, so the file can be ingested independently of the build summary.
+// The file is written regardless of ALLOW_TOOLCHAIN_REBUILDS, so conflicts can be audited even if they were not fatal.
+// If there were no conflicts, the file only contains the header.
+func RecordConflictsSummary(buildState *GraphBuildState, allowToolchainRebuilds bool, outputPath string) {
+	csvBlob := [][]string{{"Type", "File", "Version", "BuiltBy", "ToolchainRebuildsAllowed"}}
+	rebuildsAllowed := strconv.FormatBool(allowToolchainRebuilds)
+
+	// The SRPMs are only recorded by name, find their versions through the RPMs they rebuilt.
+	srpmSources := make(map[string]*pkggraph.PkgNode)
+	for _, rpm := range buildState.ConflictingRPMs() {
+		if source := buildState.ConflictingRPMSource(rpm); source != nil {
+			srpmSources[source.SRPMFileName()] = source
+		}
+	}
+
+	for _, srpm := range buildState.ConflictingSRPMs() {
+		csvBlob = append(csvBlob, []string{"SRPM", srpm, conflictVersion(srpmSources[srpm]), "", rebuildsAllowed})
+	}
+
+	for _, rpm := range buildState.ConflictingRPMs() {
+		source := buildState.ConflictingRPMSource(rpm)
+		builtBy := ""
+		if source != nil {
+			builtBy = source.SRPMFileName()
+		}
+		csvBlob = append(csvBlob, []string{"RPM", rpm, conflictVersion(source), builtBy, rebuildsAllowed})
+	}
+
+	err := writeCSVAtomically(csvBlob, nil, outputPath)
+	if err != nil {
+		logger.Log.Warnf("Failed to write conflicts CSV file '%s'. Error: %s", outputPath, err)
+	}
+}
+
+// conflictVersion returns the version of the package a conflicting file was rebuilt from, or "" if it is unknown.
+func conflictVersion(source *pkggraph.PkgNode) string {
+	if source == nil || source.VersionedPkg == nil {
+		return ""
+	}
+
+	return source.VersionedPkg.Version
+}
@@ -0,0 +1,38 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordConflictsSummaryWhenToolchainRebuildsAllowed(t *testing.T) {
+	g, _, buildNodes := buildTestSummaryGraph(t)
+	buildState := NewGraphBuildState([]string{"built-1.0-1.cm2.x86_64.rpm"})
+	buildState.RecordBuildResult(&BuildResult{
+		Node:           buildNodes["built"],
+		AncillaryNodes: []*pkggraph.PkgNode{buildNodes["built"]},
+		Attempts:       1,
+		BuiltFiles:     []string{"/mariner/out/RPMS/x86_64/built-1.0-1.cm2.x86_64.rpm"},
+	})
+
+	status := CalculateBuildStatus(g, &sync.RWMutex{}, buildState, true, false)
+	assert.True(t, status.HasConflicts)
+	assert.False(t, status.HasFatalConflicts)
+
+	outputPath := filepath.Join(t.TempDir(), "conflicts.csv")
+	RecordConflictsSummary(buildState, true, outputPath)
+
+	contents, err := os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "Type,File,Version,BuiltBy,ToolchainRebuildsAllowed\n"+
+		"SRPM,built-1.0-1.cm2.src.rpm,1.0-1.cm2,,true\n"+
+		"RPM,built-1.0-1.cm2.x86_64.rpm,1.0-1.cm2,built-1.0-1.cm2.src.rpm,true\n", string(contents))
+}
@@ -0,0 +1,85 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"strings"
+	"time"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+)
+
+// criticalPathStep is the longest chain of build time starting at a node, memoized while walking the graph.
+type criticalPathStep struct {
+	total time.Duration
+	next  *pkggraph.PkgNode
+}
+
+// CalculateCriticalPath returns the chain of dependent builds with the longest total build duration, which is the
+// minimum time the build could have taken with unlimited parallelism. The path is ordered by build order, so each build
+// node depends on the one before it. Only build nodes with a recorded build duration are included.
+// Dependency cycles are broken arbitrarily. The caller is responsible for holding the graph's read lock.
+func CalculateCriticalPath(pkgGraph *pkggraph.PkgGraph, buildState *GraphBuildState) (path []*pkggraph.PkgNode, total time.Duration) {
+	steps := make(map[*pkggraph.PkgNode]criticalPathStep)
+
+	var longestFrom func(node *pkggraph.PkgNode) time.Duration
+	longestFrom = func(node *pkggraph.PkgNode) time.Duration {
+		if step, found := steps[node]; found {
+			return step.total
+		}
+		// Guard against dependency cycles, a node already on the stack adds nothing.
+		steps[node] = criticalPathStep{}
+
+		var step criticalPathStep
+		dependencies := pkgGraph.From(node.ID())
+		for dependencies.Next() {
+			dependency := dependencies.Node().(*pkggraph.PkgNode)
+			if dependencyTotal := longestFrom(dependency); step.next == nil || dependencyTotal > step.total {
+				step.total = dependencyTotal
+				step.next = dependency
+			}
+		}
+
+		if node.Type == pkggraph.TypeLocalBuild {
+			step.total += buildState.NodeBuildDuration(node)
+		}
+		steps[node] = step
+
+		return step.total
+	}
+
+	var start *pkggraph.PkgNode
+	for _, node := range pkgGraph.AllNodes() {
+		if nodeTotal := longestFrom(node); nodeTotal > total {
+			total = nodeTotal
+			start = node
+		}
+	}
+
+	// A node in a cycle may point back to one already on the path, stop there.
+	onPath := make(map[*pkggraph.PkgNode]bool)
+	for current := start; current != nil && !onPath[current]; current = steps[current].next {
+		onPath[current] = true
+		if current.Type == pkggraph.TypeLocalBuild && buildState.NodeBuildDuration(current) > 0 {
+			// Walking from dependents to dependencies, prepend so the path is in build order.
+			path = append([]*pkggraph.PkgNode{current}, path...)
+		}
+	}
+
+	return
+}
+
+// formatCriticalPath formats a critical path as "a.src.rpm -> b.src.rpm", listing consecutive nodes of the same SRPM once.
+// The SRPMs are named with srpmName.
+func formatCriticalPath(path []*pkggraph.PkgNode, srpmName func(node *pkggraph.PkgNode) string) string {
+	srpmNames := make([]string, 0, len(path))
+	for _, node := range path {
+		name := srpmName(node)
+		if len(srpmNames) == 0 || srpmNames[len(srpmNames)-1] != name {
+			srpmNames = append(srpmNames, name)
+		}
+	}
+
+	return strings.Join(srpmNames, " -> ")
+}
@@ -0,0 +1,58 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateCriticalPath(t *testing.T) {
+	g := pkggraph.NewPkgGraph()
+	buildState := NewGraphBuildState(nil)
+
+	// "app" depends on both "lib" and "tool", the chain through the slower "lib" is the critical one.
+	libRun, libBuild := addTestPackage(t, g, "lib")
+	toolRun, toolBuild := addTestPackage(t, g, "tool")
+	_, appBuild := addTestPackage(t, g, "app")
+	assert.NoError(t, g.AddEdge(appBuild, libRun))
+	assert.NoError(t, g.AddEdge(appBuild, toolRun))
+
+	durations := map[*pkggraph.PkgNode]time.Duration{
+		libBuild:  30 * time.Minute,
+		toolBuild: 5 * time.Minute,
+		appBuild:  12 * time.Minute,
+	}
+	for node, duration := range durations {
+		recordTestResult(buildState, node, false, false, nil)
+		buildState.NodeBuildResult(node).Duration = duration
+	}
+
+	path, total := CalculateCriticalPath(g, buildState)
+	assert.Equal(t, []*pkggraph.PkgNode{libBuild, appBuild}, path)
+	assert.Equal(t, 42*time.Minute, total)
+	assert.Equal(t, "lib-1.0-1.cm2.src.rpm -> app-1.0-1.cm2.src.rpm", formatCriticalPath(path, (*pkggraph.PkgNode).SRPMFileName))
+}
+
+func TestCalculateCriticalPathWithCycle(t *testing.T) {
+	g := pkggraph.NewPkgGraph()
+	buildState := NewGraphBuildState(nil)
+
+	aRun, aBuild := addTestPackage(t, g, "a")
+	bRun, bBuild := addTestPackage(t, g, "b")
+	assert.NoError(t, g.AddEdge(aBuild, bRun))
+	assert.NoError(t, g.AddEdge(bBuild, aRun))
+
+	for _, node := range []*pkggraph.PkgNode{aBuild, bBuild} {
+		recordTestResult(buildState, node, false, false, nil)
+		buildState.NodeBuildResult(node).Duration = time.Minute
+	}
+
+	path, total := CalculateCriticalPath(g, buildState)
+	assert.Len(t, path, 2)
+	assert.Equal(t, 2*time.Minute, total)
+}
@@ -0,0 +1,63 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+	"gonum.org/v1/gonum/graph/topo"
+)
+
+// DetectCycles returns the groups of build nodes which depend on each other in a cycle.
+// Each group holds the build nodes of one strongly connected component of the graph, sorted by SRPM name,
+// and the groups are sorted by the SRPM name of their first node. Cycles which only contain run nodes are ignored.
+// The caller is responsible for holding the graph's read lock.
+func DetectCycles(pkgGraph *pkggraph.PkgGraph) (cycles [][]*pkggraph.PkgNode) {
+	for _, component := range topo.TarjanSCC(pkgGraph) {
+		// A single node can't form a cycle, the graph does not allow self loops.
+		if len(component) < 2 {
+			continue
+		}
+
+		var buildNodes []*pkggraph.PkgNode
+		for _, node := range component {
+			pkgNode := node.(*pkggraph.PkgNode)
+			if pkgNode.Type == pkggraph.TypeLocalBuild {
+				buildNodes = append(buildNodes, pkgNode)
+			}
+		}
+
+		if len(buildNodes) == 0 {
+			continue
+		}
+
+		sort.Slice(buildNodes, func(i, j int) bool {
+			return buildNodes[i].SRPMFileName() < buildNodes[j].SRPMFileName()
+		})
+		cycles = append(cycles, buildNodes)
+	}
+
+	sort.Slice(cycles, func(i, j int) bool {
+		return cycles[i][0].SRPMFileName() < cycles[j][0].SRPMFileName()
+	})
+
+	return
+}
+
+// formatCycle formats a group of cyclic build nodes as a comma separated list of their unique SRPMs, named with srpmName.
+func formatCycle(cycle []*pkggraph.PkgNode, srpmName func(node *pkggraph.PkgNode) string) string {
+	srpms := make(map[string]*pkggraph.PkgNode)
+	for _, node := range cycle {
+		srpms[node.SrpmPath] = node
+	}
+
+	srpmNames := make([]string, 0, len(srpms))
+	for _, node := range sortedNodes(srpms) {
+		srpmNames = append(srpmNames, srpmName(node))
+	}
+
+	return strings.Join(srpmNames, ", ")
+}
@@ -0,0 +1,26 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"testing"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkgjson"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectCyclesFindsBuildCycle(t *testing.T) {
+	g, _, buildNodes := buildTestSummaryGraph(t)
+	assert.Empty(t, DetectCycles(g))
+
+	// "blocked2" already depends on "blocked", close the cycle.
+	blocked2Run, err := g.FindBestPkgNode(&pkgjson.PackageVer{Name: "blocked2"})
+	assert.NoError(t, err)
+	assert.NoError(t, g.AddEdge(buildNodes["blocked"], blocked2Run.RunNode))
+
+	cycles := DetectCycles(g)
+	assert.Equal(t, [][]*pkggraph.PkgNode{{buildNodes["blocked"], buildNodes["blocked2"]}}, cycles)
+	assert.Equal(t, "blocked-1.0-1.cm2.src.rpm, blocked2-1.0-1.cm2.src.rpm", formatCycle(cycles[0], (*pkggraph.PkgNode).SRPMFileName))
+}
@@ -118,18 +118,16 @@ func FindUnblockedNodesFromResult(res *BuildResult, pkgGraph *pkggraph.PkgGraph,
 
 	// Since all the ancillary nodes are marked as available already, there may be duplicate nodes returned by the below loop.
 	// e.g. If a meta node requires two build nodes for the same SPEC, then that meta node will be reported twice.
-	// Filter the nodes to ensure no duplicates.
-	var unfilteredUnblockedNodes []*pkggraph.PkgNode
+	// Filter the nodes to ensure no duplicates, and only notify the build state once per unblocked node.
 	unblockedNodesMap := make(map[*pkggraph.PkgNode]bool)
 	for _, node := range res.AncillaryNodes {
-		unfilteredUnblockedNodes = append(unfilteredUnblockedNodes, findUnblockedNodesFromNode(pkgGraph, buildState, node)...)
-	}
-
-	for _, node := range unfilteredUnblockedNodes {
-		_, found := unblockedNodesMap[node]
-		if !found {
-			unblockedNodesMap[node] = true
-			unblockedNodes = append(unblockedNodes, node)
+		for _, unblockedNode := range findUnblockedNodesFromNode(pkgGraph, buildState, node) {
+			_, found := unblockedNodesMap[unblockedNode]
+			if !found {
+				unblockedNodesMap[unblockedNode] = true
+				unblockedNodes = append(unblockedNodes, unblockedNode)
+				buildState.notifyUnblocked(unblockedNode, node)
+			}
 		}
 	}
 
@@ -0,0 +1,70 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/logger"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/sliceutils"
+)
+
+const (
+	failedNodeColor  = "#f8d7da"
+	blockedNodeColor = "#fff3cd"
+)
+
+// RecordFailureGraphDOT stores the subgraph of failed and blocked SRPMs in to a Graphviz DOT file.
+// Each SRPM is a single node, colored by its state, with an edge to every failed or blocked SRPM it directly depends on.
+// All other SRPMs are left out to keep the graph small, render it with "dot -Tsvg".
+func RecordFailureGraphDOT(pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, buildState *GraphBuildState, outputPath string) {
+	graphMutex.RLock()
+	defer graphMutex.RUnlock()
+
+	categories := CategorizeBuildNodes(pkgGraph, buildState)
+
+	edges := make(map[string]bool)
+	for _, node := range pkgGraph.AllBuildNodes() {
+		_, isFailed := categories.Failed[node.SrpmPath]
+		_, isUnbuilt := categories.Unbuilt[node.SrpmPath]
+		if !isFailed && !isUnbuilt {
+			continue
+		}
+
+		for _, blocker := range blockingSRPMs(pkgGraph, node, categories) {
+			if blocker == node.SRPMFileName() {
+				continue
+			}
+			edges[fmt.Sprintf("\t%q -> %q;\n", node.SRPMFileName(), blocker)] = true
+		}
+	}
+
+	var dot strings.Builder
+	dot.WriteString("digraph failures {\n")
+	dot.WriteString("\tnode [shape=box, style=filled];\n")
+
+	for _, srpm := range sortedSRPMNames(categories.Failed) {
+		fmt.Fprintf(&dot, "\t%q [fillcolor=%q];\n", srpm, failedNodeColor)
+	}
+	for _, srpm := range sortedSRPMNames(categories.Unbuilt) {
+		fmt.Fprintf(&dot, "\t%q [fillcolor=%q];\n", srpm, blockedNodeColor)
+	}
+
+	sortedEdges := sliceutils.SetToSlice(edges)
+	sort.Strings(sortedEdges)
+	for _, edge := range sortedEdges {
+		dot.WriteString(edge)
+	}
+
+	dot.WriteString("}\n")
+
+	err := writeReport(dot.String(), outputPath)
+	if err != nil {
+		logger.Log.Warnf("Failed to write failure graph DOT file '%s'. Error: %s", outputPath, err)
+	}
+}
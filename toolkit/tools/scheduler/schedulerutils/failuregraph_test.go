@@ -0,0 +1,31 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordFailureGraphDOT(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+
+	outputPath := filepath.Join(t.TempDir(), "failures.dot")
+	RecordFailureGraphDOT(g, &sync.RWMutex{}, buildState, outputPath)
+
+	contents, err := os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "digraph failures {\n"+
+		"\tnode [shape=box, style=filled];\n"+
+		"\t\"failed-1.0-1.cm2.src.rpm\" [fillcolor=\"#f8d7da\"];\n"+
+		"\t\"blocked-1.0-1.cm2.src.rpm\" [fillcolor=\"#fff3cd\"];\n"+
+		"\t\"blocked2-1.0-1.cm2.src.rpm\" [fillcolor=\"#fff3cd\"];\n"+
+		"\t\"blocked-1.0-1.cm2.src.rpm\" -> \"failed-1.0-1.cm2.src.rpm\";\n"+
+		"\t\"blocked2-1.0-1.cm2.src.rpm\" -> \"blocked-1.0-1.cm2.src.rpm\";\n"+
+		"}\n", string(contents))
+}
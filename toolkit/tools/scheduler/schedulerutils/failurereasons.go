@@ -0,0 +1,149 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// otherFailureReason is the reason of failures which don't match any of the known patterns.
+	otherFailureReason = "other"
+	// timeoutFailureReason is the reason of builds killed after exceeding the build timeout.
+	timeoutFailureReason = "timeout"
+	// missingDependencyFailureReason is the reason of builds whose build requirements could not be installed.
+	missingDependencyFailureReason = "missing dependency"
+)
+
+// failureReasonPatterns are the messages of common build errors, grouped by the reason they are classified as.
+// Reasons are checked in order, the first one with a matching pattern wins. Patterns are matched case-insensitively.
+var failureReasonPatterns = []struct {
+	reason   string
+	patterns []string
+}{
+	{missingDependencyFailureReason, []string{"failed build dependencies", "nothing provides", "is needed by", "no matching packages", "unable to install the following packages", "failed to install build requirements"}},
+	{"patch apply failed", []string{"(%prep)", "hunk #", "saving rejects", "patch failed", "can't find file to patch", "reversed (or previously applied) patch"}},
+	{"test failure", []string{"(%check)", "tests failed", "test failed"}},
+	{"build failure", []string{"(%build)", "(%install)"}},
+	{timeoutFailureReason, []string{"timed out", "deadline exceeded"}},
+}
+
+// errorMessageVariables match the parts of an error message which vary between packages hitting the same problem,
+// along with the placeholder NormalizeErrorMessage replaces them with. They are applied in order.
+var errorMessageVariables = []struct {
+	pattern     *regexp.Regexp
+	placeholder string
+}{
+	{regexp.MustCompile(`\S*/\S*`), "<path>"},
+	{regexp.MustCompile(`\S+\.rpm\b`), "<rpm>"},
+	{regexp.MustCompile(`0x[0-9a-f]+`), "<n>"},
+	{regexp.MustCompile(`\d+`), "<n>"},
+	{regexp.MustCompile(`\s+`), " "},
+}
+
+// failureReasonCount is the number of failed builds classified under a reason.
+type failureReasonCount struct {
+	reason string
+	count  int
+}
+
+// ClassifyFailureReason classifies a build failure message by matching it against the messages of common build errors,
+// e.g. "missing dependency", "patch apply failed" or "test failure". Unknown errors are classified as "other".
+// The message should be the failure line found in the build log, such as
+// "error: Bad exit status from /var/tmp/rpm-tmp.Xn1x2 (%check)": the error returned by the build agent is usually
+// just the exit status of the worker.
+func ClassifyFailureReason(failureMessage string) string {
+	failureMessage = strings.ToLower(failureMessage)
+	for _, reasonPatterns := range failureReasonPatterns {
+		for _, pattern := range reasonPatterns.patterns {
+			if strings.Contains(failureMessage, pattern) {
+				return reasonPatterns.reason
+			}
+		}
+	}
+
+	return otherFailureReason
+}
+
+// NormalizeErrorMessage reduces a build failure message to its general form, so failures of different packages with the
+// same cause compare equal: the message is lowercased, paths, RPM file names and numbers are replaced with placeholders
+// and whitespace is collapsed. For example "error: Bad exit status from /var/tmp/rpm-tmp.Xn1x2 (%build)" becomes
+// "error: bad exit status from <path> (%build)".
+func NormalizeErrorMessage(errMessage string) string {
+	errMessage = strings.ToLower(errMessage)
+	for _, variable := range errorMessageVariables {
+		errMessage = variable.pattern.ReplaceAllString(errMessage, variable.placeholder)
+	}
+
+	return strings.TrimSpace(errMessage)
+}
+
+// countDistinctErrorMessages returns the number of distinct normalized failure messages among the failures.
+func countDistinctErrorMessages(failures []*BuildResult) int {
+	messages := make(map[string]bool)
+	for _, failure := range failures {
+		messages[NormalizeErrorMessage(failureMessage(failure))] = true
+	}
+
+	return len(messages)
+}
+
+// failureMessage returns the failure line parsed from the build's log, or its error if no failure line was found,
+// e.g. when the build agent failed before rpmbuild ran.
+func failureMessage(failure *BuildResult) string {
+	switch {
+	case failure.FailureMessage != "":
+		return failure.FailureMessage
+	case failure.Err != nil:
+		return failure.Err.Error()
+	default:
+		return ""
+	}
+}
+
+// failureReason returns the reason a build failed. Timed out builds and builds whose dependencies could not be
+// installed are classified by their result, all other failures by their failure message.
+func failureReason(failure *BuildResult) string {
+	switch {
+	case failure.TimedOut:
+		return timeoutFailureReason
+	case failure.FailureType == FailureInstall:
+		return missingDependencyFailureReason
+	default:
+		return ClassifyFailureReason(failureMessage(failure))
+	}
+}
+
+// failureReasonHistogram counts the failed builds per reason, sorted by descending count and then by reason.
+func failureReasonHistogram(failures []*BuildResult) (histogram []failureReasonCount) {
+	counts := make(map[string]int)
+	for _, failure := range failures {
+		counts[failureReason(failure)]++
+	}
+
+	for reason, count := range counts {
+		histogram = append(histogram, failureReasonCount{reason: reason, count: count})
+	}
+	sort.Slice(histogram, func(i, j int) bool {
+		if histogram[i].count != histogram[j].count {
+			return histogram[i].count > histogram[j].count
+		}
+		return histogram[i].reason < histogram[j].reason
+	})
+
+	return
+}
+
+// formatFailureReasonHistogram formats a histogram as "10x missing dependency, 3x patch apply failed".
+func formatFailureReasonHistogram(histogram []failureReasonCount) string {
+	entries := make([]string, 0, len(histogram))
+	for _, reasonCount := range histogram {
+		entries = append(entries, fmt.Sprintf("%dx %s", reasonCount.count, reasonCount.reason))
+	}
+
+	return strings.Join(entries, ", ")
+}
@@ -0,0 +1,146 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyFailureReason(t *testing.T) {
+	assert.Equal(t, "missing dependency", ClassifyFailureReason("error: Failed build dependencies:\n\tlibfoo-devel is needed by bar-1.0-1.x86_64"))
+	assert.Equal(t, "missing dependency", ClassifyFailureReason("Error(1301) : nothing provides libfoo >= 2.0"))
+	assert.Equal(t, "patch apply failed", ClassifyFailureReason("1 out of 3 hunks FAILED -- saving rejects to file src/main.c.rej"))
+	assert.Equal(t, "patch apply failed", ClassifyFailureReason("Hunk #2 FAILED at 104."))
+	assert.Equal(t, "test failure", ClassifyFailureReason("error: Bad exit status from /var/tmp/rpm-tmp.abc (%check)"))
+	assert.Equal(t, "timeout", ClassifyFailureReason("context deadline exceeded"))
+	assert.Equal(t, "other", ClassifyFailureReason("exit status 1"))
+	assert.Equal(t, "other", ClassifyFailureReason(""))
+}
+
+func TestBuildSummaryReportsFailureReasons(t *testing.T) {
+	failures := []*BuildResult{
+		{Err: fmt.Errorf("exit status 1"), FailureType: FailureInstall},
+		{Err: fmt.Errorf("Hunk #1 FAILED at 12.")},
+		{Err: fmt.Errorf("nothing provides libfoo")},
+		{Err: fmt.Errorf("killed"), TimedOut: true},
+		{Err: fmt.Errorf("exit status 2")},
+	}
+	assert.Equal(t, "2x missing dependency, 1x other, 1x patch apply failed, 1x timeout", formatFailureReasonHistogram(failureReasonHistogram(failures)))
+
+	g, buildState, _ := buildTestSummaryGraph(t)
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+	assert.Contains(t, output.String(), "Failure reasons: 1x other\n")
+}
+
+func TestFailureReasonFromLogFailureLine(t *testing.T) {
+	// Build logs as written by pkgworker: rpmbuild's output is logged at debug level, the worker then exits with an error.
+	const (
+		buildingLine = `time="2023-04-27T10:15:02Z" level=info msg="Building (zlib-1.2.13-1.cm2.src.rpm)."` + "\n"
+		exitLine     = `time="2023-04-27T10:16:40Z" level=fatal msg="exit status 1"` + "\n"
+	)
+
+	tests := []struct {
+		name            string
+		log             string
+		expectedMessage string
+		expectedReason  string
+	}{
+		{
+			name: "check",
+			log: buildingLine +
+				`time="2023-04-27T10:16:38Z" level=debug msg="make: *** [Makefile:310: check] Error 1"` + "\n" +
+				`time="2023-04-27T10:16:38Z" level=debug msg="error: Bad exit status from /var/tmp/rpm-tmp.Xn1x2 (%check)"` + "\n" +
+				`time="2023-04-27T10:16:38Z" level=debug msg="RPM build errors:"` + "\n" +
+				exitLine,
+			expectedMessage: "error: Bad exit status from /var/tmp/rpm-tmp.Xn1x2 (%check)",
+			expectedReason:  "test failure",
+		},
+		{
+			name: "prep",
+			log: buildingLine +
+				`time="2023-04-27T10:15:04Z" level=debug msg="1 out of 2 hunks FAILED -- saving rejects to file zlib.c.rej"` + "\n" +
+				`time="2023-04-27T10:15:04Z" level=debug msg="error: Bad exit status from /var/tmp/rpm-tmp.a8Kd2 (%prep)"` + "\n" +
+				exitLine,
+			expectedMessage: "error: Bad exit status from /var/tmp/rpm-tmp.a8Kd2 (%prep)",
+			expectedReason:  "patch apply failed",
+		},
+		{
+			name: "build",
+			log: buildingLine +
+				`time="2023-04-27T10:15:40Z" level=debug msg="make: *** [Makefile:180: all] Error 2"` + "\n" +
+				`time="2023-04-27T10:15:40Z" level=debug msg="error: Bad exit status from /var/tmp/rpm-tmp.Qp3Ze (%build)"` + "\n" +
+				exitLine,
+			expectedMessage: "error: Bad exit status from /var/tmp/rpm-tmp.Qp3Ze (%build)",
+			expectedReason:  "build failure",
+		},
+		{
+			name: "install",
+			log: buildingLine +
+				`time="2023-04-27T10:15:03Z" level=warning msg="Failed to install build requirements. stderr: Error(1011) : No matching packages\nstdout: "` + "\n" +
+				exitLine,
+			expectedMessage: `Failed to install build requirements. stderr: Error(1011) : No matching packages\nstdout:`,
+			expectedReason:  "missing dependency",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			logFile := filepath.Join(t.TempDir(), "zlib-1.2.13-1.cm2.src.rpm.log")
+			assert.NoError(t, os.WriteFile(logFile, []byte(test.log), 0644))
+
+			// The agent only reports the worker's exit status.
+			failure := &BuildResult{Err: fmt.Errorf("exit status 1"), LogFile: logFile, FailureType: parseFailureType(logFile)}
+			failure.FailureLine, failure.FailureMessage = parseFailureLine(logFile)
+
+			assert.Equal(t, test.expectedMessage, failure.FailureMessage)
+			assert.Equal(t, test.expectedReason, failureReason(failure))
+		})
+	}
+}
+
+func TestFailureReasonFallsBackToError(t *testing.T) {
+	assert.Equal(t, "missing dependency", failureReason(&BuildResult{Err: fmt.Errorf("nothing provides libfoo")}))
+	assert.Equal(t, "other", failureReason(&BuildResult{Err: fmt.Errorf("exit status 1")}))
+	assert.Equal(t, "other", failureReason(&BuildResult{}))
+}
+
+func TestNormalizeErrorMessage(t *testing.T) {
+	assert.Equal(t, "error: bad exit status from <path> (%build)", NormalizeErrorMessage("error: Bad exit status from /var/tmp/rpm-tmp.Qp3Ze (%build)"))
+	assert.Equal(t, "failed to build <path> exit status <n>", NormalizeErrorMessage("Failed to build /mariner/out/SRPMS/foo-1.0-1.cm2.src.rpm:  exit status 2"))
+	assert.Equal(t, "nothing provides <rpm> needed by <rpm>", NormalizeErrorMessage("nothing provides libfoo.so.1-2.0.rpm needed by bar-1.0-1.cm2.x86_64.rpm"))
+	assert.Equal(t, "segfault at <n>", NormalizeErrorMessage("segfault at 0x7ffd1234\n"))
+}
+
+func TestBuildSummaryCountsDistinctFailureReasons(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+
+	// The same error for two packages is counted once.
+	for _, name := range []string{"lib1", "lib2"} {
+		_, buildNode := addTestPackage(t, g, name)
+		recordTestResult(buildState, buildNode, false, false, fmt.Errorf("failed to build %s: exit status 1", buildNode.SrpmPath))
+	}
+
+	// Failures with the same exit status are told apart by their log failure line.
+	for name, failureMessage := range map[string]string{
+		"lib3": "error: Bad exit status from /var/tmp/rpm-tmp.Qp3Ze (%build)",
+		"lib4": "error: Bad exit status from /var/tmp/rpm-tmp.a8Kd2 (%build)",
+		"lib5": "error: Bad exit status from /var/tmp/rpm-tmp.Xn1x2 (%check)",
+	} {
+		_, buildNode := addTestPackage(t, g, name)
+		recordTestResult(buildState, buildNode, false, false, fmt.Errorf("exit status 1"))
+		buildState.NodeBuildResult(buildNode).FailureMessage = failureMessage
+	}
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+	assert.Contains(t, output.String(), "\nDistinct failure reasons: 4\n")
+}
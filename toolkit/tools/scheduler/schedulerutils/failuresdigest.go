@@ -0,0 +1,106 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/logger"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+)
+
+// failureDigestEntry is a single SRPM listed in the failures digest.
+type failureDigestEntry struct {
+	srpm  string
+	lines []string
+}
+
+// GetFailureLogPaths returns the log file of every failed build, in the order the failures were recorded, so they can
+// be collected without parsing the summary. Failures without a log file are skipped.
+// Returns an empty slice if there were no failures.
+func GetFailureLogPaths(buildState *GraphBuildState) (logPaths []string) {
+	logPaths = make([]string, 0)
+	seenLogPaths := make(map[string]bool)
+	for _, failure := range buildState.BuildFailures() {
+		if failure.LogFile != "" && !seenLogPaths[failure.LogFile] {
+			seenLogPaths[failure.LogFile] = true
+			logPaths = append(logPaths, failure.LogFile)
+		}
+	}
+
+	return
+}
+
+// RecordFailuresDigest stores a compact, triage oriented list of the build failures in to a text file.
+// Failed SRPMs are listed with their error and log file, blocked SRPMs are listed with the failed SRPM(s) at the root
+// of their blocking chain. Successful and unaffected SRPMs are omitted, entries are sorted by SRPM name.
+// If there are no failures the file is not written.
+func RecordFailuresDigest(pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, buildState *GraphBuildState, outputPath string) {
+	graphMutex.RLock()
+	defer graphMutex.RUnlock()
+
+	categories := CategorizeBuildNodes(pkgGraph, buildState)
+	if len(categories.Failures) == 0 {
+		logger.Log.Infof("No failed SRPMs, skipping the failures digest '%s'", outputPath)
+		return
+	}
+
+	var entries []failureDigestEntry
+	for _, failure := range categories.Failures {
+		entries = append(entries, failureDigestEntry{
+			srpm: failure.Node.SRPMFileName(),
+			lines: []string{
+				fmt.Sprintf("error: %s", failure.Err),
+				fmt.Sprintf("log: %s", failure.LogFile),
+			},
+		})
+	}
+
+	for _, node := range categories.Unbuilt {
+		rootBlockers := rootBlockingSRPMs(TraceBlockingRoot(pkgGraph, node, buildState))
+		if len(rootBlockers) == 0 {
+			// Blocked only by unresolved dependencies, there is no failure to triage.
+			continue
+		}
+
+		entries = append(entries, failureDigestEntry{
+			srpm:  node.SRPMFileName(),
+			lines: []string{fmt.Sprintf("root blocker: %s", strings.Join(rootBlockers, ", "))},
+		})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].srpm < entries[j].srpm
+	})
+
+	var digest strings.Builder
+	for _, entry := range entries {
+		digest.WriteString(entry.srpm + "\n")
+		for _, line := range entry.lines {
+			digest.WriteString("    " + line + "\n")
+		}
+	}
+
+	err := writeReport(digest.String(), outputPath)
+	if err != nil {
+		logger.Log.Warnf("Failed to write failures digest file '%s'. Error: %s", outputPath, err)
+	}
+}
+
+// rootBlockingSRPMs returns the unique SRPM names of the root failures at the end of each blocking path.
+func rootBlockingSRPMs(blockingPaths [][]*pkggraph.PkgNode) (rootSRPMs []string) {
+	seen := make(map[string]bool)
+	for _, path := range blockingPaths {
+		rootSRPM := path[len(path)-1].SRPMFileName()
+		if !seen[rootSRPM] {
+			seen[rootSRPM] = true
+			rootSRPMs = append(rootSRPMs, rootSRPM)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,51 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordFailuresDigest(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+	buildState.NodeBuildResult(buildNodes["failed"]).LogFile = "/logs/failed.log"
+
+	outputPath := filepath.Join(t.TempDir(), "failures.txt")
+	RecordFailuresDigest(g, &sync.RWMutex{}, buildState, outputPath)
+
+	contents, err := os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "blocked-1.0-1.cm2.src.rpm\n"+
+		"    root blocker: failed-1.0-1.cm2.src.rpm\n"+
+		"blocked2-1.0-1.cm2.src.rpm\n"+
+		"    root blocker: failed-1.0-1.cm2.src.rpm\n"+
+		"failed-1.0-1.cm2.src.rpm\n"+
+		"    error: build failed\n"+
+		"    log: /logs/failed.log\n", string(contents))
+}
+
+func TestRecordFailuresDigestSkipsFileWithoutFailures(t *testing.T) {
+	g, _, _ := buildTestSummaryGraph(t)
+
+	outputPath := filepath.Join(t.TempDir(), "failures.txt")
+	RecordFailuresDigest(g, &sync.RWMutex{}, NewGraphBuildState(nil), outputPath)
+
+	assert.NoFileExists(t, outputPath)
+}
+
+func TestGetFailureLogPaths(t *testing.T) {
+	emptyState := NewGraphBuildState(nil)
+	assert.NotNil(t, GetFailureLogPaths(emptyState))
+	assert.Empty(t, GetFailureLogPaths(emptyState))
+
+	_, buildState, buildNodes := buildTestSummaryGraph(t)
+	buildState.NodeBuildResult(buildNodes["failed"]).LogFile = "/logs/failed.log"
+	buildState.NodeBuildResult(buildNodes["built"]).LogFile = "/logs/built.log"
+	assert.Equal(t, []string{"/logs/failed.log"}, GetFailureLogPaths(buildState))
+}
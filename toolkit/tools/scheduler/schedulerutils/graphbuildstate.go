@@ -6,17 +6,63 @@ package schedulerutils
 import (
 	"path/filepath"
 	"sort"
+	"time"
 
 	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/logger"
 	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
 	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/sliceutils"
 )
 
+// CacheMissReason explains why a build node could not use a cached copy of its RPMs.
+type CacheMissReason int
+
+// Valid values for CacheMissReason type
+const (
+	CacheMissNone              CacheMissReason = iota // The node used the cache, or was never considered for it
+	CacheMissDisabled          CacheMissReason = iota // Using the cache was disabled for the build
+	CacheMissRebuildRequested  CacheMissReason = iota // The user explicitly requested the package to be rebuilt
+	CacheMissDependencyRebuilt CacheMissReason = iota // One of the node's dependencies was rebuilt instead of being cached
+	CacheMissRPMsAbsent        CacheMissReason = iota // Some of the SRPM's RPMs were not found, usually because the sources changed
+)
+
+func (r CacheMissReason) String() string {
+	switch r {
+	case CacheMissNone:
+		return "None"
+	case CacheMissDisabled:
+		return "Cache disabled"
+	case CacheMissRebuildRequested:
+		return "Rebuild requested"
+	case CacheMissDependencyRebuilt:
+		return "Dependency rebuilt"
+	case CacheMissRPMsAbsent:
+		return "Cached RPMs absent"
+	default:
+		return "Unknown"
+	}
+}
+
+// UnblockedCallback is invoked when the last blocking dependency of a node completes, making the node buildable.
+// - node is the node which was unblocked.
+// - unblockedBy is the completed dependency which unblocked it.
+// The callback is invoked with the graph's read lock held, it must not modify the graph.
+type UnblockedCallback func(node, unblockedBy *pkggraph.PkgNode)
+
+// ConflictCallback is invoked when a build result adds a new entry to the toolchain conflicts.
+// - rpm is the base name of the toolchain *.rpm file which was rebuilt, as listed by ConflictingRPMs.
+// - builtBy is the build node whose SRPM produced it, as listed by ConflictingSRPMs.
+// The callback is invoked from RecordBuildResult, once per conflicting RPM, and must not record build results itself.
+type ConflictCallback func(rpm string, builtBy *pkggraph.PkgNode)
+
 // nodeState represents the build state of a single node
 type nodeState struct {
-	available bool
-	cached    bool
-	usedDelta bool
+	available  bool
+	cached     bool
+	usedDelta  bool
+	skipped    bool
+	touched    bool
+	staleCache bool
+	result     *BuildResult
 }
 
 // GraphBuildState represents the build state of a graph.
@@ -27,6 +73,9 @@ type GraphBuildState struct {
 	reservedFiles    map[string]bool
 	conflictingRPMs  map[string]bool
 	conflictingSRPMs map[string]bool
+	conflictSources  map[string]*pkggraph.PkgNode
+	onUnblocked      UnblockedCallback
+	onConflict       ConflictCallback
 }
 
 // NewGraphBuildState returns a new GraphBuildState.
@@ -42,6 +91,7 @@ func NewGraphBuildState(reservedFiles []string) (g *GraphBuildState) {
 		reservedFiles:    filesMap,
 		conflictingRPMs:  make(map[string]bool),
 		conflictingSRPMs: make(map[string]bool),
+		conflictSources:  make(map[string]*pkggraph.PkgNode),
 	}
 }
 
@@ -74,6 +124,70 @@ func (g *GraphBuildState) IsNodeDelta(node *pkggraph.PkgNode) bool {
 	return state != nil && state.usedDelta
 }
 
+// IsNodeSkipped returns true if the requested node's build was skipped per user request.
+func (g *GraphBuildState) IsNodeSkipped(node *pkggraph.PkgNode) bool {
+	state := g.nodeToState[node]
+	return state != nil && state.skipped
+}
+
+// WasNodeBuilt returns true if the requested node's SRPM was sent to a build agent during this build.
+func (g *GraphBuildState) WasNodeBuilt(node *pkggraph.PkgNode) bool {
+	state := g.nodeToState[node]
+	return state != nil && state.touched
+}
+
+// IsNodeCacheStale returns true if the requested node used cached delta RPMs with an older changelog than its spec.
+func (g *GraphBuildState) IsNodeCacheStale(node *pkggraph.PkgNode) bool {
+	state := g.nodeToState[node]
+	return state != nil && state.staleCache
+}
+
+// NodeBuildResult returns the build result recorded for the requested node, or nil if the node has not been processed.
+func (g *GraphBuildState) NodeBuildResult(node *pkggraph.PkgNode) *BuildResult {
+	state := g.nodeToState[node]
+	if state == nil {
+		return nil
+	}
+	return state.result
+}
+
+// NodeBuildDuration returns the time spent building the requested node.
+// Returns zero if the node was not built, for example if it was cached.
+func (g *GraphBuildState) NodeBuildDuration(node *pkggraph.PkgNode) time.Duration {
+	res := g.NodeBuildResult(node)
+	if res == nil {
+		return 0
+	}
+	return res.Duration
+}
+
+// NodeCacheMissReason returns the reason the requested node could not use the cache.
+// Returns CacheMissNone if the node has not been processed or did not need to be built.
+func (g *GraphBuildState) NodeCacheMissReason(node *pkggraph.PkgNode) CacheMissReason {
+	res := g.NodeBuildResult(node)
+	if res == nil {
+		return CacheMissNone
+	}
+	return res.CacheMissReason
+}
+
+// WasNodeForcedRebuild returns true if the requested node was built even though all of its cached RPMs were available,
+// for example because it was on the list of packages to rebuild.
+func (g *GraphBuildState) WasNodeForcedRebuild(node *pkggraph.PkgNode) bool {
+	res := g.NodeBuildResult(node)
+	return res != nil && res.CacheAvailable && !res.UsedCache && !res.Skipped
+}
+
+// NodeCacheSource returns where the requested node's cached RPMs came from, see BuildResult.CacheSource.
+// Returns an empty string if the node was not restored from the cache.
+func (g *GraphBuildState) NodeCacheSource(node *pkggraph.PkgNode) string {
+	res := g.NodeBuildResult(node)
+	if res == nil || !res.UsedCache {
+		return ""
+	}
+	return res.CacheSource
+}
+
 // ActiveBuilds returns a map of Node IDs to BuildRequests that represents all outstanding builds.
 func (g *GraphBuildState) ActiveBuilds() map[int64]*BuildRequest {
 	return g.activeBuilds
@@ -113,6 +227,38 @@ func (g *GraphBuildState) ConflictingSRPMs() (srpms []string) {
 	return srpms
 }
 
+// ConflictingRPMSource returns the build node whose build produced a conflicting *.rpm file, as listed by ConflictingRPMs.
+// Returns nil if the RPM did not conflict with the toolchain.
+func (g *GraphBuildState) ConflictingRPMSource(rpm string) *pkggraph.PkgNode {
+	return g.conflictSources[rpm]
+}
+
+// SetUnblockedCallback sets the callback invoked whenever a node is unblocked by a completed dependency.
+// Passing nil removes the callback.
+func (g *GraphBuildState) SetUnblockedCallback(callback UnblockedCallback) {
+	g.onUnblocked = callback
+}
+
+// notifyUnblocked invokes the unblocked callback, if one is set.
+func (g *GraphBuildState) notifyUnblocked(node, unblockedBy *pkggraph.PkgNode) {
+	if g.onUnblocked != nil {
+		g.onUnblocked(node, unblockedBy)
+	}
+}
+
+// SetConflictCallback sets the callback invoked whenever a new toolchain conflict is recorded, for example to abort the
+// build as soon as a toolchain package is rebuilt. Passing nil removes the callback.
+func (g *GraphBuildState) SetConflictCallback(callback ConflictCallback) {
+	g.onConflict = callback
+}
+
+// notifyConflict invokes the conflict callback, if one is set.
+func (g *GraphBuildState) notifyConflict(rpm string, builtBy *pkggraph.PkgNode) {
+	if g.onConflict != nil {
+		g.onConflict(rpm, builtBy)
+	}
+}
+
 // RecordBuildRequest records a build request in the graph build state.
 func (g *GraphBuildState) RecordBuildRequest(req *BuildRequest) {
 	logger.Log.Debugf("Recording build request: %s", req.Node.FriendlyName())
@@ -133,7 +279,9 @@ func (g *GraphBuildState) isConflictWithToolchain(fileToCheck string) (hadConfli
 // RecordBuildResult records a build result in the graph build state.
 // - It will record the result as a failure if applicable.
 // - It will record all ancillary nodes of the result.
-func (g *GraphBuildState) RecordBuildResult(res *BuildResult, allowToolchainRebuilds bool) {
+// - It will record any toolchain conflicts, even if toolchain rebuilds are allowed. Whether they are fatal is decided by
+// CalculateBuildStatus, so they can still be audited. The conflict callback is invoked for every new conflict.
+func (g *GraphBuildState) RecordBuildResult(res *BuildResult) {
 
 	logger.Log.Debugf("Recording build result: %s", res.Node.FriendlyName())
 
@@ -144,25 +292,47 @@ func (g *GraphBuildState) RecordBuildResult(res *BuildResult, allowToolchainRebu
 	}
 
 	state := &nodeState{
-		available: res.Err == nil,
-		cached:    res.UsedCache,
-		usedDelta: res.WasDelta,
+		available:  res.Err == nil,
+		cached:     res.UsedCache,
+		usedDelta:  res.WasDelta,
+		skipped:    res.Skipped,
+		touched:    res.Attempts > 0,
+		staleCache: res.CacheMayBeStale,
+		result:     res,
 	}
 
 	for _, node := range res.AncillaryNodes {
 		g.nodeToState[node] = state
 	}
 
-	if !allowToolchainRebuilds && !res.Skipped && !res.UsedCache {
+	if !res.Skipped && !res.UsedCache {
 		for _, file := range res.BuiltFiles {
 			if g.isConflictWithToolchain(file) {
-				g.conflictingRPMs[filepath.Base(file)] = true
+				rpm := filepath.Base(file)
+				isNewConflict := !g.conflictingRPMs[rpm]
+
+				g.conflictingRPMs[rpm] = true
+				g.conflictSources[rpm] = res.Node
 				g.conflictingSRPMs[filepath.Base(res.Node.SrpmPath)] = true
+
+				if isNewConflict {
+					g.notifyConflict(rpm, res.Node)
+				}
 			}
 		}
 	} else {
-		logger.Log.Debugf("skipping checking conflicts since this is either not a built node (%v) or the ALLOW_TOOLCHAIN_REBUILDS flag was set to 'y'", res.Node)
+		logger.Log.Debugf("skipping checking conflicts since this is not a built node (%v)", res.Node)
 	}
 
 	return
 }
+
+// isRemoteSourceRepo checks if a node's source repo is a remote repo, as opposed to the local cache or no repo at all.
+func isRemoteSourceRepo(sourceRepo string) bool {
+	switch sourceRepo {
+	case "", "<LOCAL>", "<NO_REPO>":
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeCacheSourceIsOnlySetForCachedResults(t *testing.T) {
+	_, buildState, buildNodes := buildTestSummaryGraph(t)
+	assert.Equal(t, "/mariner/out/RPMS/x86_64", buildState.NodeCacheSource(buildNodes["cached"]))
+	assert.Equal(t, "mariner-official-base", buildState.NodeCacheSource(buildNodes["delta"]))
+	assert.Empty(t, buildState.NodeCacheSource(buildNodes["built"]))
+	assert.Empty(t, buildState.NodeCacheSource(buildNodes["blocked"]))
+}
+
+func TestUnblockedCallback(t *testing.T) {
+	g := pkggraph.NewPkgGraph()
+	buildState := NewGraphBuildState(nil)
+
+	libRun, libBuild := addTestPackage(t, g, "lib")
+	_, appBuild := addTestPackage(t, g, "app")
+	assert.NoError(t, g.AddEdge(appBuild, libRun))
+
+	type unblockEvent struct {
+		node, unblockedBy *pkggraph.PkgNode
+	}
+	var events []unblockEvent
+	buildState.SetUnblockedCallback(func(node, unblockedBy *pkggraph.PkgNode) {
+		events = append(events, unblockEvent{node, unblockedBy})
+	})
+
+	libResult := &BuildResult{Node: libBuild, AncillaryNodes: []*pkggraph.PkgNode{libBuild}}
+	buildState.RecordBuildResult(libResult)
+	FindUnblockedNodesFromResult(libResult, g, &sync.RWMutex{}, buildState)
+
+	libRunResult := &BuildResult{Node: libRun, AncillaryNodes: []*pkggraph.PkgNode{libRun}}
+	buildState.RecordBuildResult(libRunResult)
+	FindUnblockedNodesFromResult(libRunResult, g, &sync.RWMutex{}, buildState)
+
+	assert.Equal(t, []unblockEvent{{libRun, libBuild}, {appBuild, libRun}}, events)
+}
+
+func TestConflictCallbackIsInvokedForNewConflicts(t *testing.T) {
+	g := pkggraph.NewPkgGraph()
+	buildState := NewGraphBuildState([]string{"gcc-1.0-1.cm2.x86_64.rpm"})
+	_, gccBuild := addTestPackage(t, g, "gcc")
+	_, otherBuild := addTestPackage(t, g, "other")
+
+	var conflicts []string
+	buildState.SetConflictCallback(func(rpm string, builtBy *pkggraph.PkgNode) {
+		conflicts = append(conflicts, rpm+" by "+builtBy.SRPMFileName())
+	})
+
+	recordTestResult(buildState, otherBuild, false, false, nil)
+	assert.Empty(t, conflicts)
+
+	recordTestResult(buildState, gccBuild, false, false, nil)
+	assert.Equal(t, []string{"gcc-1.0-1.cm2.x86_64.rpm by gcc-1.0-1.cm2.src.rpm"}, conflicts)
+
+	// Recording the same conflict again doesn't add an entry.
+	recordTestResult(buildState, gccBuild, false, false, nil)
+	assert.Len(t, conflicts, 1)
+	assert.Equal(t, []string{"gcc-1.0-1.cm2.src.rpm"}, buildState.ConflictingSRPMs())
+}
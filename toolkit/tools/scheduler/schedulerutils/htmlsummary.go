@@ -0,0 +1,110 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"sync"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/file"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/logger"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+)
+
+// failureLogTailLines is the number of lines from the end of each failure's log file embedded in the HTML report.
+const failureLogTailLines = 50
+
+// htmlSummaryStyle is the inline stylesheet of the HTML report, the report must not depend on any external assets.
+const htmlSummaryStyle = `body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.8em; text-align: left; }
+th { background: #eee; }
+tr.built td { background: #e6f4ea; }
+tr.prebuilt td { background: #e8f0fe; }
+tr.skipped td { background: #f1f3f4; }
+tr.failed td { background: #fce8e6; }
+tr.blocked td { background: #fef7e0; }
+details { margin-bottom: 0.5em; }
+summary { cursor: pointer; }
+pre { background: #f8f8f8; border: 1px solid #ddd; padding: 0.5em; overflow-x: auto; }
+`
+
+// RecordBuildSummaryHTML stores the summary in to a self-contained HTML file.
+// The report contains the build counts, a color-coded table of package states and an expandable section
+// for every failure showing the end of its build log.
+func RecordBuildSummaryHTML(pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, buildState *GraphBuildState, outputPath string) {
+	graphMutex.RLock()
+	defer graphMutex.RUnlock()
+
+	categories := CategorizeBuildNodes(pkgGraph, buildState)
+
+	var report strings.Builder
+
+	report.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>Build summary</title>\n")
+	fmt.Fprintf(&report, "<style>\n%s</style>\n</head>\n<body>\n", htmlSummaryStyle)
+
+	report.WriteString("<h1>Build summary</h1>\n<table>\n<tr><th>State</th><th>Count</th></tr>\n")
+	fmt.Fprintf(&report, "<tr class=\"built\"><td>Built</td><td>%d</td></tr>\n", len(categories.Built))
+	fmt.Fprintf(&report, "<tr class=\"prebuilt\"><td>AlreadyAvailable</td><td>%d</td></tr>\n", len(categories.AlreadyAvailable))
+	fmt.Fprintf(&report, "<tr class=\"prebuilt\"><td>PreBuilt</td><td>%d</td></tr>\n", len(categories.Prebuilt))
+	fmt.Fprintf(&report, "<tr class=\"prebuilt\"><td>PreBuiltDelta</td><td>%d</td></tr>\n", len(categories.PrebuiltDelta))
+	fmt.Fprintf(&report, "<tr class=\"skipped\"><td>Skipped</td><td>%d</td></tr>\n", len(categories.Skipped))
+	fmt.Fprintf(&report, "<tr class=\"failed\"><td>Failed</td><td>%d</td></tr>\n", len(categories.Failures))
+	fmt.Fprintf(&report, "<tr class=\"blocked\"><td>Blocked</td><td>%d</td></tr>\n", len(categories.Unbuilt))
+	fmt.Fprintf(&report, "<tr><td>Unresolved dependencies</td><td>%d</td></tr>\n", len(categories.Unresolved))
+	report.WriteString("</table>\n")
+
+	report.WriteString("<h2>Packages</h2>\n<table>\n<tr><th>Package</th><th>State</th><th>Blocker</th></tr>\n")
+
+	addRows := func(nodes map[string]*pkggraph.PkgNode, state, rowClass string, withBlockers bool) {
+		for _, node := range sortedNodes(nodes) {
+			blockers := ""
+			if withBlockers {
+				blockers = strings.Join(blockingSRPMs(pkgGraph, node, categories), ", ")
+			}
+			fmt.Fprintf(&report, "<tr class=\"%s\"><td>%s</td><td>%s</td><td>%s</td></tr>\n", rowClass, html.EscapeString(node.SRPMFileName()), state, html.EscapeString(blockers))
+		}
+	}
+
+	addRows(categories.Failed, "Failed", "failed", true)
+	addRows(categories.Unbuilt, "Blocked", "blocked", true)
+	addRows(categories.Built, "Built", "built", false)
+	addRows(categories.AlreadyAvailable, "AlreadyAvailable", "prebuilt", false)
+	addRows(categories.Prebuilt, "PreBuilt", "prebuilt", false)
+	addRows(categories.PrebuiltDelta, "PreBuiltDelta", "prebuilt", false)
+	addRows(categories.Skipped, "Skipped", "skipped", false)
+	report.WriteString("</table>\n")
+
+	if len(categories.Failures) != 0 {
+		fmt.Fprintf(&report, "<h2>Failed SRPMs (%d)</h2>\n", len(categories.Failures))
+		for _, failure := range sortedFailures(categories.Failures) {
+			fmt.Fprintf(&report, "<details>\n<summary>%s: %s</summary>\n", html.EscapeString(failure.Node.SRPMFileName()), html.EscapeString(failure.Err.Error()))
+			fmt.Fprintf(&report, "<p>Log file: <code>%s</code></p>\n", html.EscapeString(failure.LogFile))
+			fmt.Fprintf(&report, "<pre>%s</pre>\n</details>\n", html.EscapeString(logFileTail(failure.LogFile, failureLogTailLines)))
+		}
+	}
+
+	report.WriteString("</body>\n</html>\n")
+
+	err := writeReport(report.String(), outputPath)
+	if err != nil {
+		logger.Log.Warnf("Failed to write HTML file '%s'. Error: %s", outputPath, err)
+	}
+}
+
+// logFileTail returns up to the last maxLines lines of a log file, or a short note if the log can't be read.
+func logFileTail(logFile string, maxLines int) string {
+	lines, err := file.ReadLines(logFile)
+	if err != nil {
+		return fmt.Sprintf("Unable to read log file: %s", err)
+	}
+
+	if len(lines) > maxLines {
+		lines = lines[len(lines)-maxLines:]
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,61 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/logger"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/retry"
+)
+
+const (
+	// postSummaryAttempts is the number of times PostBuildSummary tries to send the summary.
+	postSummaryAttempts = 3
+	// postSummaryRetryDelay is the delay before the second attempt, each later attempt waits one more delay.
+	postSummaryRetryDelay = time.Second
+	// postSummaryTimeout bounds each attempt, including reading the response.
+	postSummaryTimeout = 30 * time.Second
+)
+
+// PostBuildSummary sends the JSON summary written by RecordBuildSummaryJSON to url in the body of a POST request, e.g.
+// to push the results to a build dashboard. Failed attempts are retried, any response status other than 2xx counts as
+// a failure. If all attempts fail, a warning is logged: reporting the summary never fails the build.
+func PostBuildSummary(pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, buildState *GraphBuildState, url string) {
+	graphMutex.RLock()
+	summary := newBuildSummaryDocument(pkgGraph, buildState)
+	graphMutex.RUnlock()
+
+	jsonBlob, err := json.Marshal(summary)
+	if err != nil {
+		logger.Log.Warnf("Failed to encode the build summary for '%s'. Error: %s", url, err)
+		return
+	}
+
+	client := &http.Client{Timeout: postSummaryTimeout}
+	err = retry.Run(func() error {
+		response, postErr := client.Post(url, "application/json", bytes.NewReader(jsonBlob))
+		if postErr != nil {
+			logger.Log.Debugf("Failed to post the build summary to '%s'. Error: %s", url, postErr)
+			return postErr
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+			logger.Log.Debugf("Posting the build summary to '%s' returned status %s", url, response.Status)
+			return fmt.Errorf("invalid response: %s", response.Status)
+		}
+
+		return nil
+	}, postSummaryAttempts, postSummaryRetryDelay)
+	if err != nil {
+		logger.Log.Warnf("Failed to post the build summary to '%s' after %d attempts. Error: %s", url, postSummaryAttempts, err)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/logger"
+	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostBuildSummaryRetriesFailedRequests(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+
+	var (
+		requests int
+		received BuildSummaryDocument
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer server.Close()
+
+	PostBuildSummary(g, &sync.RWMutex{}, buildState, server.URL)
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, 1, received.Counts.Failed)
+	assert.Equal(t, 2, received.Counts.Blocked)
+}
+
+func TestPostBuildSummaryWarnsOnNetworkFailure(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	hook := &logrustest.Hook{}
+	previousHooks := logger.Log.ReplaceHooks(make(logrus.LevelHooks))
+	t.Cleanup(func() { logger.Log.ReplaceHooks(previousHooks) })
+	logger.Log.AddHook(hook)
+
+	PostBuildSummary(g, &sync.RWMutex{}, buildState, url)
+	assert.Equal(t, logrus.WarnLevel, hook.LastEntry().Level)
+	assert.Contains(t, hook.LastEntry().Message, "Failed to post the build summary")
+}
@@ -0,0 +1,132 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/jsonutils"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/logger"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+)
+
+// BuildSummaryDocument is the top level document written by RecordBuildSummaryJSON and RecordBuildSummaryYAML.
+type BuildSummaryDocument struct {
+	Counts   BuildSummaryCounts `json:"counts" yaml:"counts"`
+	Packages []PackageSummary   `json:"packages" yaml:"packages"`
+}
+
+// BuildSummaryCounts mirrors the counts logged by PrintBuildSummary.
+type BuildSummaryCounts struct {
+	Built            int `json:"built" yaml:"built"`
+	AlreadyAvailable int `json:"alreadyAvailable" yaml:"already_available"`
+	Prebuilt         int `json:"prebuilt" yaml:"prebuilt"`
+	PrebuiltDelta    int `json:"prebuiltDelta" yaml:"prebuilt_delta"`
+	Skipped          int `json:"skipped" yaml:"skipped"`
+	Failed           int `json:"failed" yaml:"failed"`
+	Blocked          int `json:"blocked" yaml:"blocked"`
+	Unresolved       int `json:"unresolved" yaml:"unresolved"`
+	RPMConflicts     int `json:"rpmConflicts" yaml:"rpm_conflicts"`
+	SRPMConflicts    int `json:"srpmConflicts" yaml:"srpm_conflicts"`
+}
+
+// PackageSummary describes the final state of a single SRPM.
+type PackageSummary struct {
+	Package  string   `json:"package" yaml:"package"`
+	State    string   `json:"state" yaml:"state"`
+	SrpmPath string   `json:"srpmPath" yaml:"srpm_path"`
+	Blockers []string `json:"blockers" yaml:"blockers"`
+	IsDelta  bool     `json:"isDelta" yaml:"is_delta"`
+}
+
+// RecordBuildSummaryJSON stores the summary in to a JSON file.
+// It records the same package states as RecordBuildSummary along with the counts printed by PrintBuildSummary.
+func RecordBuildSummaryJSON(pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, buildState *GraphBuildState, outputPath string) {
+	graphMutex.RLock()
+	defer graphMutex.RUnlock()
+
+	summary := newBuildSummaryDocument(pkgGraph, buildState)
+
+	err := writeJSONReport(summary, outputPath)
+	if err != nil {
+		logger.Log.Warnf("Failed to write JSON file '%s'. Error: %s", outputPath, err)
+	}
+}
+
+// writeJSONReport writes data as indented JSON to outputPath, or to stdout if outputPath is stdoutOutputPath.
+func writeJSONReport(data interface{}, outputPath string) (err error) {
+	if outputPath != stdoutOutputPath {
+		return jsonutils.WriteJSONFile(outputPath, data)
+	}
+
+	jsonBlob, err := json.MarshalIndent(data, "", " ")
+	if err != nil {
+		return
+	}
+
+	return writeReport(string(jsonBlob)+"\n", outputPath)
+}
+
+// newBuildSummaryDocument categorizes the build nodes and collects the counts and per-package states of the build.
+// The packages are sorted by name.
+// The caller is responsible for holding the graph's read lock.
+func newBuildSummaryDocument(pkgGraph *pkggraph.PkgGraph, buildState *GraphBuildState) (summary *BuildSummaryDocument) {
+	categories := CategorizeBuildNodes(pkgGraph, buildState)
+
+	summary = &BuildSummaryDocument{
+		Counts: BuildSummaryCounts{
+			Built:            len(categories.Built),
+			AlreadyAvailable: len(categories.AlreadyAvailable),
+			Prebuilt:         len(categories.Prebuilt),
+			PrebuiltDelta:    len(categories.PrebuiltDelta),
+			Skipped:          len(categories.Skipped),
+			Failed:           len(categories.Failures),
+			Blocked:          len(categories.Unbuilt),
+			Unresolved:       len(categories.Unresolved),
+			RPMConflicts:     len(buildState.ConflictingRPMs()),
+			SRPMConflicts:    len(buildState.ConflictingSRPMs()),
+		},
+		// Always initialize the slice so an empty build is serialized as an empty array instead of null.
+		Packages: make([]PackageSummary, 0),
+	}
+
+	addPackages := func(nodes map[string]*pkggraph.PkgNode, state string, withBlockers bool) {
+		for _, node := range nodes {
+			pkgSummary := PackageSummary{
+				Package:  filepath.Base(node.SrpmPath),
+				State:    state,
+				SrpmPath: node.SrpmPath,
+				Blockers: make([]string, 0),
+				IsDelta:  buildState.IsNodeDelta(node),
+			}
+
+			if withBlockers {
+				pkgSummary.Blockers = blockingSRPMs(pkgGraph, node, categories)
+			}
+
+			summary.Packages = append(summary.Packages, pkgSummary)
+		}
+	}
+
+	addPackages(categories.Built, "Built", false)
+	addPackages(categories.AlreadyAvailable, "AlreadyAvailable", false)
+	addPackages(categories.Prebuilt, "PreBuilt", false)
+	addPackages(categories.PrebuiltDelta, "PreBuiltDelta", false)
+	addPackages(categories.Skipped, "Skipped", false)
+	addPackages(categories.Failed, "Failed", true)
+	addPackages(categories.Unbuilt, "Unbuilt", true)
+
+	// The categories are maps, sort the packages like the summary csv so the report is the same from run to run.
+	sort.Slice(summary.Packages, func(i, j int) bool {
+		if summary.Packages[i].Package != summary.Packages[j].Package {
+			return summary.Packages[i].Package < summary.Packages[j].Package
+		}
+		return summary.Packages[i].SrpmPath < summary.Packages[j].SrpmPath
+	})
+
+	return
+}
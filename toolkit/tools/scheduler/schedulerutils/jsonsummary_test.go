@@ -0,0 +1,48 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordBuildSummaryJSONSortsPackages(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+	testDir := t.TempDir()
+
+	var reports []string
+	for _, name := range []string{"first.json", "second.json"} {
+		outputPath := filepath.Join(testDir, name)
+		RecordBuildSummaryJSON(g, &sync.RWMutex{}, buildState, outputPath)
+
+		contents, err := os.ReadFile(outputPath)
+		assert.NoError(t, err)
+		reports = append(reports, string(contents))
+	}
+	assert.Equal(t, reports[0], reports[1])
+
+	var summary BuildSummaryDocument
+	assert.NoError(t, json.Unmarshal([]byte(reports[0]), &summary))
+
+	var packages []string
+	for _, pkgSummary := range summary.Packages {
+		packages = append(packages, pkgSummary.Package)
+	}
+	assert.Equal(t, []string{
+		"available-1.0-1.cm2.src.rpm",
+		"blocked-1.0-1.cm2.src.rpm",
+		"blocked2-1.0-1.cm2.src.rpm",
+		"built-1.0-1.cm2.src.rpm",
+		"cached-1.0-1.cm2.src.rpm",
+		"delta-1.0-1.cm2.src.rpm",
+		"failed-1.0-1.cm2.src.rpm",
+		"skipped-1.0-1.cm2.src.rpm",
+	}, packages)
+}
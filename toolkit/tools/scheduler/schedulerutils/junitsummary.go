@@ -0,0 +1,144 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/logger"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+)
+
+const (
+	junitSuiteName = "package-build"
+	junitClassName = "srpm"
+)
+
+// junitTestSuite is the root element of the JUnit report written by RecordBuildSummaryJUnit.
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase represents a single SRPM in the JUnit report.
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+// junitFailure holds the details of a failed SRPM build.
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// junitSkipped marks an SRPM which was blocked from building or skipped per user request.
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// RecordBuildSummaryJUnit stores the summary in to a JUnit XML file.
+// Built and prebuilt SRPMs are reported as passed test cases, failed SRPMs as failures and blocked or
+// user-skipped SRPMs as skipped.
+func RecordBuildSummaryJUnit(pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, buildState *GraphBuildState, outputPath string) {
+	graphMutex.RLock()
+	defer graphMutex.RUnlock()
+
+	categories := CategorizeBuildNodes(pkgGraph, buildState)
+
+	suite := junitTestSuite{
+		Name:      junitSuiteName,
+		TestCases: make([]junitTestCase, 0),
+	}
+
+	addPassed := func(nodes map[string]*pkggraph.PkgNode) {
+		for _, node := range nodes {
+			suite.TestCases = append(suite.TestCases, newJUnitTestCase(node, buildState))
+		}
+	}
+
+	addPassed(categories.Built)
+	addPassed(categories.AlreadyAvailable)
+	addPassed(categories.Prebuilt)
+	addPassed(categories.PrebuiltDelta)
+
+	for _, failure := range categories.Failures {
+		testCase := newJUnitTestCase(failure.Node, buildState)
+		testCase.Failure = &junitFailure{
+			Message: failure.Err.Error(),
+			Body:    fmt.Sprintf("Failed to build %s, error: %s, for details see: %s", failure.Node.SRPMFileName(), failure.Err, failure.LogFile),
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+		suite.Failures++
+	}
+
+	for _, node := range categories.Skipped {
+		testCase := newJUnitTestCase(node, buildState)
+		testCase.Skipped = &junitSkipped{
+			Message: "Skipped per user request",
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+		suite.Skipped++
+	}
+
+	for _, node := range categories.Unbuilt {
+		testCase := newJUnitTestCase(node, buildState)
+		testCase.Skipped = &junitSkipped{
+			Message: fmt.Sprintf("Blocked by: %s", formatBlockingPaths(TraceBlockingRoot(pkgGraph, node, buildState), (*pkggraph.PkgNode).SRPMFileName)),
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+		suite.Skipped++
+	}
+
+	sort.SliceStable(suite.TestCases, func(i, j int) bool {
+		return suite.TestCases[i].Name < suite.TestCases[j].Name
+	})
+
+	var totalSeconds float64
+	for _, failure := range categories.Failures {
+		totalSeconds += failure.Duration.Seconds()
+	}
+	for _, node := range categories.Built {
+		totalSeconds += buildState.NodeBuildDuration(node).Seconds()
+	}
+	suite.Tests = len(suite.TestCases)
+	suite.Time = formatJUnitSeconds(totalSeconds)
+
+	xmlBytes, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		logger.Log.Warnf("Failed to generate JUnit report. Error: %s", err)
+		return
+	}
+
+	err = writeReport(xml.Header+string(xmlBytes), outputPath)
+	if err != nil {
+		logger.Log.Warnf("Failed to write JUnit file '%s'. Error: %s", outputPath, err)
+	}
+}
+
+// newJUnitTestCase creates a passing JUnit test case for a build node.
+func newJUnitTestCase(node *pkggraph.PkgNode, buildState *GraphBuildState) junitTestCase {
+	return junitTestCase{
+		Name:      node.SRPMFileName(),
+		ClassName: junitClassName,
+		Time:      formatJUnitSeconds(buildState.NodeBuildDuration(node).Seconds()),
+	}
+}
+
+// formatJUnitSeconds formats a number of seconds the way JUnit reports expect them.
+func formatJUnitSeconds(seconds float64) string {
+	return fmt.Sprintf("%.3f", seconds)
+}
@@ -0,0 +1,63 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/logger"
+)
+
+// knownIssue is a compiled error signature of a known issue and the ticket tracking it.
+type knownIssue struct {
+	signature *regexp.Regexp
+	ticket    string
+}
+
+// compileKnownIssues compiles a map of error signature regular expressions to ticket IDs, sorted by signature so the
+// first matching issue is the same on every run. Invalid signatures are logged and skipped.
+func compileKnownIssues(knownIssues map[string]string) (compiled []knownIssue) {
+	signatures := make([]string, 0, len(knownIssues))
+	for signature := range knownIssues {
+		signatures = append(signatures, signature)
+	}
+	sort.Strings(signatures)
+
+	for _, signature := range signatures {
+		expression, err := regexp.Compile(signature)
+		if err != nil {
+			logger.Log.Warnf("Invalid known issue signature '%s', skipping it. Error: %s", signature, err)
+			continue
+		}
+		compiled = append(compiled, knownIssue{signature: expression, ticket: knownIssues[signature]})
+	}
+
+	return
+}
+
+// annotateKnownIssues fills the Known Issue column of the failed SRPMs' rows with the ticket of the first known issue
+// matching the build error, rows of unmatched failures are left blank. Must be called before the states are relabeled.
+func annotateKnownIssues(rows []map[string]string, buildState *GraphBuildState, knownIssues map[string]string) {
+	compiled := compileKnownIssues(knownIssues)
+	if len(compiled) == 0 {
+		return
+	}
+
+	tickets := make(map[string]string)
+	for _, failure := range buildState.BuildFailures() {
+		for _, issue := range compiled {
+			if issue.signature.MatchString(failure.Err.Error()) {
+				tickets[failure.Node.SrpmPath] = issue.ticket
+				break
+			}
+		}
+	}
+
+	for _, row := range rows {
+		if row["State"] == "Failed" {
+			row["Known Issue"] = tickets[row["SRPM Path"]]
+		}
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordBuildSummaryAnnotatesKnownIssues(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+	recordTestResult(buildState, buildNodes["blocked"], false, false, fmt.Errorf("error: Bad exit status from /var/tmp/rpm-tmp.1234 (%%check)"))
+	outputPath := filepath.Join(t.TempDir(), "summary.csv")
+
+	knownIssues := map[string]string{
+		`Bad exit status .* \(%check\)`: "BUG-1",
+		`unrelated`:                     "BUG-2",
+		`(invalid`:                      "BUG-3",
+	}
+	RecordBuildSummary(g, &sync.RWMutex{}, buildState, outputPath, SummaryCSVOptions{Columns: []string{"Package", "State", "Known Issue"}, KnownIssues: knownIssues})
+
+	contents, err := os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(contents), "\nblocked-1.0-1.cm2.src.rpm,Failed,BUG-1\n")
+	// Unmatched failures are left blank.
+	assert.Contains(t, string(contents), "\nfailed-1.0-1.cm2.src.rpm,Failed,\n")
+	assert.Contains(t, string(contents), "\nbuilt-1.0-1.cm2.src.rpm,Built,\n")
+}
@@ -0,0 +1,77 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/logger"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+)
+
+// RecordBuildSummaryMarkdown stores the summary in to a GitHub-flavored markdown file, suitable for PR comments.
+// The report contains the build counts, a table of package states and a collapsible section listing the failures.
+func RecordBuildSummaryMarkdown(pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, buildState *GraphBuildState, outputPath string) {
+	graphMutex.RLock()
+	defer graphMutex.RUnlock()
+
+	categories := CategorizeBuildNodes(pkgGraph, buildState)
+
+	var report strings.Builder
+
+	report.WriteString("## Build summary\n\n")
+	report.WriteString("| State | Count |\n")
+	report.WriteString("| --- | ---: |\n")
+	fmt.Fprintf(&report, "| Built | %d |\n", len(categories.Built))
+	fmt.Fprintf(&report, "| AlreadyAvailable | %d |\n", len(categories.AlreadyAvailable))
+	fmt.Fprintf(&report, "| PreBuilt | %d |\n", len(categories.Prebuilt))
+	fmt.Fprintf(&report, "| PreBuiltDelta | %d |\n", len(categories.PrebuiltDelta))
+	fmt.Fprintf(&report, "| Skipped | %d |\n", len(categories.Skipped))
+	fmt.Fprintf(&report, "| Failed | %d |\n", len(categories.Failures))
+	fmt.Fprintf(&report, "| Blocked | %d |\n", len(categories.Unbuilt))
+	fmt.Fprintf(&report, "| Unresolved dependencies | %d |\n", len(categories.Unresolved))
+
+	report.WriteString("\n### Packages\n\n")
+	report.WriteString("| Package | State | Blocker |\n")
+	report.WriteString("| --- | --- | --- |\n")
+
+	addRows := func(nodes map[string]*pkggraph.PkgNode, state string, withBlockers bool) {
+		for _, node := range sortedNodes(nodes) {
+			blockers := ""
+			if withBlockers {
+				blockers = strings.Join(blockingSRPMs(pkgGraph, node, categories), ", ")
+			}
+			fmt.Fprintf(&report, "| %s | %s | %s |\n", escapeMarkdownTableCell(node.SRPMFileName()), state, escapeMarkdownTableCell(blockers))
+		}
+	}
+
+	addRows(categories.Failed, "Failed", true)
+	addRows(categories.Unbuilt, "Blocked", true)
+	addRows(categories.Built, "Built", false)
+	addRows(categories.AlreadyAvailable, "AlreadyAvailable", false)
+	addRows(categories.Prebuilt, "PreBuilt", false)
+	addRows(categories.PrebuiltDelta, "PreBuiltDelta", false)
+	addRows(categories.Skipped, "Skipped", false)
+
+	if len(categories.Failures) != 0 {
+		fmt.Fprintf(&report, "\n<details>\n<summary>Failed SRPMs (%d)</summary>\n\n", len(categories.Failures))
+		for _, failure := range sortedFailures(categories.Failures) {
+			fmt.Fprintf(&report, "- **%s**: `%s` (log: `%s`)\n", failure.Node.SRPMFileName(), failure.Err, failure.LogFile)
+		}
+		report.WriteString("\n</details>\n")
+	}
+
+	err := writeReport(report.String(), outputPath)
+	if err != nil {
+		logger.Log.Warnf("Failed to write markdown file '%s'. Error: %s", outputPath, err)
+	}
+}
+
+// escapeMarkdownTableCell escapes characters which would break a markdown table row.
+func escapeMarkdownTableCell(cell string) string {
+	cell = strings.ReplaceAll(cell, "|", "\\|")
+	return strings.ReplaceAll(cell, "\n", " ")
+}
@@ -0,0 +1,66 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/logger"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+)
+
+// rpmFileSize is the size of a single built RPM file.
+type rpmFileSize struct {
+	path string
+	size int64
+}
+
+// builtRPMSizes returns the sizes of all RPMs produced by the built nodes, largest first.
+// RPMs which can't be found on disk are skipped.
+func builtRPMSizes(builtNodes map[string]*pkggraph.PkgNode, buildState *GraphBuildState) (rpmSizes []rpmFileSize) {
+	for _, node := range builtNodes {
+		res := buildState.NodeBuildResult(node)
+		if res == nil {
+			continue
+		}
+
+		for _, rpmPath := range res.BuiltFiles {
+			fileInfo, err := os.Stat(rpmPath)
+			if err != nil {
+				logger.Log.Debugf("Unable to read the size of '%s', skipping it. Error: %s", rpmPath, err)
+				continue
+			}
+			rpmSizes = append(rpmSizes, rpmFileSize{path: rpmPath, size: fileInfo.Size()})
+		}
+	}
+
+	sort.Slice(rpmSizes, func(i, j int) bool {
+		if rpmSizes[i].size != rpmSizes[j].size {
+			return rpmSizes[i].size > rpmSizes[j].size
+		}
+		return filepath.Base(rpmSizes[i].path) < filepath.Base(rpmSizes[j].path)
+	})
+
+	return
+}
+
+// formatByteSize formats a size in bytes using binary units, e.g. "1.5 MiB".
+func formatByteSize(size int64) string {
+	const unit = 1024
+
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	divisor, exponent := int64(unit), 0
+	for remaining := size / unit; remaining >= unit; remaining /= unit {
+		divisor *= unit
+		exponent++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(divisor), "KMGTPE"[exponent])
+}
@@ -0,0 +1,32 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintBuildSummaryToReportsOutputSizes(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+
+	rpmDir := t.TempDir()
+	smallRPM := filepath.Join(rpmDir, "small.rpm")
+	largeRPM := filepath.Join(rpmDir, "large.rpm")
+	assert.NoError(t, os.WriteFile(smallRPM, make([]byte, 512), 0644))
+	assert.NoError(t, os.WriteFile(largeRPM, make([]byte, 3*1024), 0644))
+	buildState.NodeBuildResult(buildNodes["built"]).BuiltFiles = []string{smallRPM, largeRPM, filepath.Join(rpmDir, "missing.rpm")}
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{IncludeOutputSizes: true})
+
+	summary := output.String()
+	assert.Contains(t, summary, "Total output size: 3.5 KiB in 2 RPMs\n")
+	assert.Contains(t, summary, "Largest 2 built RPMs:\n--> large.rpm (3.0 KiB)\n--> small.rpm (512 B)\n")
+}
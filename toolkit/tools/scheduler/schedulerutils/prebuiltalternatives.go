@@ -0,0 +1,61 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/logger"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkgjson"
+)
+
+// PackageQuery looks up the packages providing pkgVer in the configured repos, like the WhatProvides method of a repo cloner.
+type PackageQuery func(pkgVer *pkgjson.PackageVer) (packageNames []string, err error)
+
+// FindPrebuiltAlternatives queries the repos for the RPMs each failed SRPM would have produced, at the exact version
+// from the local specs. It returns the providing packages found for each failed SRPM, keyed by the SRPM's file name,
+// so the build summary can flag the failures a prebuilt package could substitute. Failed queries are logged and skipped.
+func FindPrebuiltAlternatives(pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, buildState *GraphBuildState, query PackageQuery) (alternatives map[string][]string) {
+	graphMutex.RLock()
+	defer graphMutex.RUnlock()
+
+	alternatives = make(map[string][]string)
+
+	failedSRPMs := make(map[string]bool)
+	for _, failure := range buildState.BuildFailures() {
+		failedSRPMs[failure.Node.SrpmPath] = true
+	}
+
+	queried := make(map[string]bool)
+	for _, node := range pkgGraph.AllRunNodes() {
+		if node.Type != pkggraph.TypeLocalRun || !failedSRPMs[node.SrpmPath] {
+			continue
+		}
+
+		// An SRPM may have several run nodes for the same package, query each package once.
+		queryKey := node.SrpmPath + node.VersionedPkg.String()
+		if queried[queryKey] {
+			continue
+		}
+		queried[queryKey] = true
+
+		packageNames, err := query(node.VersionedPkg)
+		if err != nil {
+			logger.Log.Debugf("No prebuilt alternative found for '%s': %s", node.VersionedPkg, err)
+			continue
+		}
+
+		srpm := node.SRPMFileName()
+		alternatives[srpm] = append(alternatives[srpm], packageNames...)
+	}
+
+	for srpm, packageNames := range alternatives {
+		sort.Strings(packageNames)
+		alternatives[srpm] = packageNames
+	}
+
+	return
+}
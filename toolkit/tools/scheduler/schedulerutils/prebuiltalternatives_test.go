@@ -0,0 +1,37 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkgjson"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindPrebuiltAlternatives(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+
+	var queried []string
+	query := func(pkgVer *pkgjson.PackageVer) (packageNames []string, err error) {
+		queried = append(queried, pkgVer.Name)
+		if pkgVer.Name != "failed" {
+			return nil, fmt.Errorf("could not resolve %s", pkgVer.Name)
+		}
+		return []string{"failed-1.0-1.cm2.x86_64"}, nil
+	}
+
+	alternatives := FindPrebuiltAlternatives(g, &sync.RWMutex{}, buildState, query)
+	// Only the failed SRPM is queried.
+	assert.Equal(t, []string{"failed"}, queried)
+	assert.Equal(t, map[string][]string{"failed-1.0-1.cm2.src.rpm": {"failed-1.0-1.cm2.x86_64"}}, alternatives)
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{PrebuiltAlternatives: alternatives})
+	assert.Contains(t, output.String(), "Failed SRPMs with prebuilt alternatives (i.e., matching RPMs are available in the configured repos):\n"+
+		"--> failed-1.0-1.cm2.src.rpm (prebuilt available: failed-1.0-1.cm2.x86_64)\n")
+}
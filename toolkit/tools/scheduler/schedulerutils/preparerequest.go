@@ -5,6 +5,7 @@ package schedulerutils
 
 import (
 	"sync"
+	"time"
 
 	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/logger"
 	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
@@ -38,9 +39,10 @@ func ConvertNodesToRequests(pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMute
 			PkgGraph:       pkgGraph,
 			AncillaryNodes: []*pkggraph.PkgNode{node},
 			IsDelta:        node.State == pkggraph.StateDelta,
+			QueuedTime:     time.Now(),
 		}
 
-		req.CanUseCache = isCacheAllowed && canUseCacheForNode(pkgGraph, req.Node, packagesToRebuild, buildState)
+		setCacheUsage(req, pkgGraph, packagesToRebuild, buildState, isCacheAllowed)
 
 		requests = append(requests, req)
 	}
@@ -63,9 +65,10 @@ func ConvertNodesToRequests(pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMute
 			PkgGraph:       pkgGraph,
 			AncillaryNodes: nodes,
 			IsDelta:        hasADeltaNode,
+			QueuedTime:     time.Now(),
 		}
 
-		req.CanUseCache = isCacheAllowed && canUseCacheForNode(pkgGraph, req.Node, packagesToRebuild, buildState)
+		setCacheUsage(req, pkgGraph, packagesToRebuild, buildState, isCacheAllowed)
 
 		requests = append(requests, req)
 	}
@@ -73,11 +76,22 @@ func ConvertNodesToRequests(pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMute
 	return
 }
 
+// setCacheUsage sets if a request can use the cache, and if not, why.
+func setCacheUsage(req *BuildRequest, pkgGraph *pkggraph.PkgGraph, packagesToRebuild []*pkgjson.PackageVer, buildState *GraphBuildState, isCacheAllowed bool) {
+	if !isCacheAllowed {
+		req.CanUseCache = false
+		req.CacheMissReason = CacheMissDisabled
+		return
+	}
+
+	req.CanUseCache, req.CacheMissReason, req.RebuiltDep = canUseCacheForNode(pkgGraph, req.Node, packagesToRebuild, buildState)
+}
+
 // canUseCacheForNode checks if the cache can be used for a given node.
 // - It will check if the node corresponds to an entry in packagesToRebuild.
 // - It will check if all dependencies of the node were also cached. Exceptions:
 //   - "TypePreBuilt" nodes must use the cache and have no dependencies to check.
-func canUseCacheForNode(pkgGraph *pkggraph.PkgGraph, node *pkggraph.PkgNode, packagesToRebuild []*pkgjson.PackageVer, buildState *GraphBuildState) (canUseCache bool) {
+func canUseCacheForNode(pkgGraph *pkggraph.PkgGraph, node *pkggraph.PkgNode, packagesToRebuild []*pkgjson.PackageVer, buildState *GraphBuildState) (canUseCache bool, missReason CacheMissReason, rebuiltDep *pkggraph.PkgNode) {
 	// The "TypePreBuilt" nodes always use the cache.
 	if node.Type == pkggraph.TypePreBuilt {
 		canUseCache = true
@@ -89,6 +103,7 @@ func canUseCacheForNode(pkgGraph *pkggraph.PkgGraph, node *pkggraph.PkgNode, pac
 	canUseCache = !sliceutils.Contains(packagesToRebuild, packageVer, sliceutils.PackageVerMatch)
 	if !canUseCache {
 		logger.Log.Debugf("Marking (%s) for rebuild per user request", packageVer)
+		missReason = CacheMissRebuildRequested
 		return
 	}
 
@@ -100,6 +115,8 @@ func canUseCacheForNode(pkgGraph *pkggraph.PkgGraph, node *pkggraph.PkgNode, pac
 		if !buildState.IsNodeCached(dependency) {
 			logger.Log.Debugf("Can't use cached version of %v because %v is rebuilding", node.FriendlyName(), dependency.FriendlyName())
 			canUseCache = false
+			missReason = CacheMissDependencyRebuilt
+			rebuiltDep = dependency
 			break
 		}
 	}
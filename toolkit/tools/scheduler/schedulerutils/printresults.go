@@ -4,276 +4,1709 @@
 package schedulerutils
 
 import (
+	"compress/gzip"
 	"encoding/csv"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/file"
 	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/logger"
 	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/sliceutils"
+	"github.com/sirupsen/logrus"
 )
 
-// PrintBuildResult prints a build result to the logger.
-func PrintBuildResult(res *BuildResult) {
-	baseSRPMName := res.Node.SRPMFileName()
+const (
+	// slowestBuildsToList is the number of built SRPMs listed in the summary's slowest builds section.
+	slowestBuildsToList = 10
+	// memoryHungryBuildsToList is the number of SRPMs listed in the summary's peak memory section.
+	memoryHungryBuildsToList = 10
+	// largestRPMsToList is the number of built RPMs listed in the summary's largest RPMs section.
+	largestRPMsToList = 10
+	// gzipExtension is the extension of summary files which should be gzip compressed.
+	gzipExtension = ".gz"
+	// stdoutOutputPath is the output path which writes a summary file to stdout instead, e.g. to pipe it to other tools.
+	// It is honored by every summary format written to a single file.
+	stdoutOutputPath = "-"
+)
 
-	if res.Err != nil {
-		logger.Log.Errorf("Failed to build %s, error: %s, for details see: %s", baseSRPMName, res.Err, res.LogFile)
+// summaryStdout is where summary files are written to if their output path is stdoutOutputPath.
+var summaryStdout io.Writer = os.Stdout
+
+// summaryStates are the states of the SRPMs in the summary csv, and the file RecordBuildSummaryPerState writes each of them to.
+var summaryStates = []struct {
+	name     string
+	fileName string
+}{
+	{"Built", "built.csv"},
+	{"AlreadyAvailable", "already-available.csv"},
+	{"PreBuilt", "prebuilt.csv"},
+	{"PreBuiltDelta", "prebuilt-delta.csv"},
+	{"Skipped", "skipped.csv"},
+	{"Failed", "failed.csv"},
+	{"Unbuilt", "blocked.csv"},
+}
+
+var (
+	// SummaryColumns are all of the columns RecordBuildSummary can write.
+	SummaryColumns = []string{"Package", "State", "Blocker", "Blocker Chain", "Architecture", "Attempts", "Cache Source", "Version", "Release", "Duration", "Cores", "Patches", "Sources", "Depth", "Queue Wait", "Known Issue", "SRPM Path"}
+	// DefaultSummaryColumns are the columns RecordBuildSummary writes if no columns are selected.
+	DefaultSummaryColumns = []string{"Package", "State", "Blocker"}
+)
+
+// SummaryOptions controls the optional parts of the summary printed by PrintBuildSummary and PrintBuildSummaryTo.
+// The zero value prints the full summary without any of the optional sections.
+type SummaryOptions struct {
+	MaxFailuresListed    int                 // Limits the number of failed SRPMs listed individually, 0 or less lists all of them
+	BuildStartTime       time.Time           // Used to report the total build time, omitted if zero
+	PackageFilter        []string            // Restricts the summary to SRPMs whose base name matches one of the glob patterns, if not empty
+	IncludeOutputSizes   bool                // Reports the total size of the built RPMs and the largest ones
+	StrictUnresolved     bool                // Reports unresolved dependencies as build failures, logged with the fatal toolchain conflicts
+	ExpectedDuration     time.Duration       // Warns if the build took longer, requires BuildStartTime. No warning if zero
+	ExcludeToolchain     bool                // Leaves the SRPMs producing toolchain RPMs out of the counts and listings, conflicts are still reported
+	Verbosity            SummaryVerbosity    // Selects which parts of the summary are printed, toolchain conflicts are always printed
+	PrebuiltAlternatives map[string][]string // Packages available in the repos for each failed SRPM's file name, see FindPrebuiltAlternatives
+	Deliverables         []string            // SRPM base name glob patterns of standalone deliverables, never reported as unused
+	MaxNodesPerSRPM      int                 // Lists the SRPMs with more graph nodes, e.g. specs generating too many subpackages. Not listed if 0
+	FullSRPMPaths        bool                // Lists the SRPMs by their full path instead of their base name, to tell apart SRPMs from different source trees
+}
+
+// SummaryCSVOptions selects what RecordBuildSummary writes to the summary csv. The zero value writes the
+// DefaultSummaryColumns to a new csv.
+type SummaryCSVOptions struct {
+	Columns          []string                  // Which of the SummaryColumns are written, and in which order. DefaultSummaryColumns are written if empty
+	AppendToExisting bool                      // Merges the summary with an existing csv at the output path if it has the same columns
+	StateLabel       func(state string) string // Renames the states written to the State column (e.g. "Built" -> "SUCCESS"), written as-is if nil
+	PackageLabel     func(srpm string) string  // Renames the SRPMs in the Package, SRPM Path, Blocker and Blocker Chain columns, written as-is if nil
+	FullSRPMPaths    bool                      // Names the SRPMs in the Package, Blocker and Blocker Chain columns by their full path instead of their base name
+	KnownIssues      map[string]string         // Error signature regular expressions of known issues mapped to the tickets tracking them
+	Metadata         *SummaryMetadata          // Written as a "#" comment header identifying the build, no header is written if nil
+}
+
+// SummaryMetadata identifies the build a summary csv was recorded for, so an archived csv can be traced back to it.
+// It is written as "#" comment lines ahead of the csv header, consumers which don't expect it can skip those lines.
+type SummaryMetadata struct {
+	BuildID        string    // Identifier of the build, e.g. the pipeline run ID
+	Timestamp      time.Time // Time the build started, omitted if zero
+	ToolkitVersion string    // Version of the toolkit which ran the build, omitted if empty
+}
+
+// commentLines returns the metadata as "key: value" lines, or no lines if m is nil.
+func (m *SummaryMetadata) commentLines() (lines []string) {
+	if m == nil {
 		return
 	}
 
-	if res.Node.Type == pkggraph.TypeLocalBuild {
-		if res.Skipped {
-			logger.Log.Warnf("Skipped build for '%s' per user request. RPMs expected to be present: %v", baseSRPMName, res.BuiltFiles)
-		} else if res.UsedCache {
-			logger.Log.Infof("Prebuilt: %s -> %v", baseSRPMName, res.BuiltFiles)
-		} else {
-			logger.Log.Infof("Built: %s -> %v", baseSRPMName, res.BuiltFiles)
-		}
-	} else {
-		logger.Log.Debugf("Processed node %s", res.Node.FriendlyName())
+	lines = append(lines, fmt.Sprintf("build-id: %s", m.BuildID))
+	if !m.Timestamp.IsZero() {
+		lines = append(lines, fmt.Sprintf("timestamp: %s", m.Timestamp.UTC().Format(time.RFC3339)))
 	}
+	if m.ToolkitVersion != "" {
+		lines = append(lines, fmt.Sprintf("toolkit-version: %s", m.ToolkitVersion))
+	}
+
+	return
 }
 
-// RecordBuildSummary stores the summary in to a csv.
-func RecordBuildSummary(pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, buildState *GraphBuildState, outputPath string) {
+// BuildNodeCategories groups the build nodes of a graph by their final build state.
+// Each node map is keyed by the SRPM path of its nodes, every SRPM is found in exactly one of them.
+type BuildNodeCategories struct {
+	Failures         []*BuildResult               // All failed build results, in the order they were recorded
+	Built            map[string]*pkggraph.PkgNode // SRPMs built during this build
+	AlreadyAvailable map[string]*pkggraph.PkgNode // SRPMs available without being built or restored from the cache by this build
+	Prebuilt         map[string]*pkggraph.PkgNode // SRPMs restored from the cache
+	PrebuiltDelta    map[string]*pkggraph.PkgNode // SRPMs skipped because delta mode found them in a repo
+	Skipped          map[string]*pkggraph.PkgNode // SRPMs whose build was skipped per user request
+	Failed           map[string]*pkggraph.PkgNode // SRPMs which failed to build
+	Unbuilt          map[string]*pkggraph.PkgNode // SRPMs blocked from building
+	Unresolved       map[string]bool              // Unresolved dependencies found in the graph
+}
+
+// CacheHitRate returns the fraction of the SRPMs which had to be built that were restored from the cache or found in a
+// repo instead, i.e. (prebuilt + prebuilt delta) / (built + prebuilt + prebuilt delta). It is zero if nothing had to be built.
+func (c *BuildNodeCategories) CacheHitRate() float64 {
+	cacheHits := len(c.Prebuilt) + len(c.PrebuiltDelta)
+	buildable := len(c.Built) + cacheHits
+	if buildable == 0 {
+		return 0
+	}
+
+	return float64(cacheHits) / float64(buildable)
+}
 
+// GetBuildSummary returns the build nodes of the graph grouped by their final build state, along with any
+// unresolved dependencies. It is safe to call while other routines modify the graph.
+func GetBuildSummary(pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, buildState *GraphBuildState) (summary *BuildNodeCategories) {
 	graphMutex.RLock()
 	defer graphMutex.RUnlock()
 
-	failedSRPMs := make(map[string]*pkggraph.PkgNode)
-	failures := buildState.BuildFailures()
-	for _, failure := range failures {
-		failedSRPMs[failure.Node.SrpmPath] = failure.Node
-	}
+	return CategorizeBuildNodes(pkgGraph, buildState)
+}
 
-	prebuiltSRPMs := make(map[string]*pkggraph.PkgNode)
-	prebuiltDeltaSRPMS := make(map[string]*pkggraph.PkgNode)
-	builtSRPMs := make(map[string]*pkggraph.PkgNode)
-	unbuiltSRPMs := make(map[string]*pkggraph.PkgNode)
-	unresolvedDependencies := make(map[string]bool)
+// CategorizeBuildNodes sorts all build nodes in the graph into built, already available, prebuilt, prebuilt delta, skipped,
+// failed and unbuilt nodes.
+// It also collects any unresolved dependencies found in the graph.
+// The caller is responsible for holding the graph's read lock.
+func CategorizeBuildNodes(pkgGraph *pkggraph.PkgGraph, buildState *GraphBuildState) (categories *BuildNodeCategories) {
+	categories = &BuildNodeCategories{
+		Failures:         buildState.BuildFailures(),
+		Built:            make(map[string]*pkggraph.PkgNode),
+		AlreadyAvailable: make(map[string]*pkggraph.PkgNode),
+		Prebuilt:         make(map[string]*pkggraph.PkgNode),
+		PrebuiltDelta:    make(map[string]*pkggraph.PkgNode),
+		Skipped:          make(map[string]*pkggraph.PkgNode),
+		Failed:           make(map[string]*pkggraph.PkgNode),
+		Unbuilt:          make(map[string]*pkggraph.PkgNode),
+		Unresolved:       make(map[string]bool),
+	}
 
-	buildNodes := pkgGraph.AllBuildNodes()
-	for _, node := range buildNodes {
+	for _, failure := range categories.Failures {
+		categories.Failed[failure.Node.SrpmPath] = failure.Node
+	}
 
-		//  node can be a delta if it was build or cached. If it was cached we used the cached rpm. If it is not cached
+	for _, node := range pkgGraph.AllBuildNodes() {
+		// A node can be a delta if it was built or cached. If it was cached we used the cached rpm. If it is not cached
 		// that means it was built and we discard the delta rpm.
 		if buildState.IsNodeCached(node) {
 			if buildState.IsNodeDelta(node) {
-				prebuiltDeltaSRPMS[node.SrpmPath] = node
+				categories.PrebuiltDelta[node.SrpmPath] = node
 			} else {
-				prebuiltSRPMs[node.SrpmPath] = node
+				categories.Prebuilt[node.SrpmPath] = node
 			}
 			continue
+		} else if buildState.IsNodeSkipped(node) {
+			// Skipped nodes are available to other nodes, but they were never actually built.
+			categories.Skipped[node.SrpmPath] = node
+			continue
 		} else if buildState.IsNodeAvailable(node) {
-			builtSRPMs[node.SrpmPath] = node
+			if buildState.WasNodeBuilt(node) {
+				categories.Built[node.SrpmPath] = node
+			} else {
+				categories.AlreadyAvailable[node.SrpmPath] = node
+			}
 			continue
 		}
 
-		_, found := failedSRPMs[node.SrpmPath]
+		_, found := categories.Failed[node.SrpmPath]
 		if !found {
-			unbuiltSRPMs[node.SrpmPath] = node
+			categories.Unbuilt[node.SrpmPath] = node
 		}
 	}
 
 	for _, node := range pkgGraph.AllRunNodes() {
 		if node.State == pkggraph.StateUnresolved {
-			unresolvedDependencies[node.VersionedPkg.String()] = true
+			categories.Unresolved[node.VersionedPkg.String()] = true
 		}
 	}
 
-	csvBlob := [][]string{{"Package", "State", "Blocker"}}
+	return
+}
+
+// PrintBuildResult prints a build result to the logger and notifies all registered ResultObservers.
+// Build node results are logged with the srpm, state, logfile and duration fields for structured log consumers.
+// - quiet: don't log successfully built and prebuilt SRPMs, failures and warnings are still logged.
+func PrintBuildResult(res *BuildResult, quiet bool) {
+	notifyResultObservers(res)
+
+	if res.Node.Type != pkggraph.TypeLocalBuild && res.Err == nil {
+		logger.Log.Debugf("Processed node %s", res.Node.FriendlyName())
+		return
+	}
+
+	baseSRPMName := res.Node.SRPMFileName()
+	resultLog := logger.Log.WithFields(logrus.Fields{
+		"srpm":    baseSRPMName,
+		"state":   buildResultState(res),
+		"logfile": res.LogFile,
+		// Use seconds so the field can be filtered on numerically.
+		"duration": res.Duration.Seconds(),
+	})
 
-	for srpm := range builtSRPMs {
-		csvBlob = append(csvBlob, []string{filepath.Base(builtSRPMs[srpm].SrpmPath), "Built"})
+	if res.Err != nil {
+		failureLocation := failureLogLocation(res)
+		if res.TimedOut {
+			resultLog.Errorf("Build of %s exceeded timeout, error: %s, for details see: %s", baseSRPMName, res.Err, failureLocation)
+		} else if res.FailureType == FailureInstall {
+			resultLog.Errorf("Failed to install build dependencies for %s, error: %s, for details see: %s", baseSRPMName, res.Err, failureLocation)
+		} else {
+			resultLog.Errorf("Failed to build %s, error: %s, for details see: %s", baseSRPMName, res.Err, failureLocation)
+		}
+		return
 	}
 
-	for srpm := range prebuiltSRPMs {
-		csvBlob = append(csvBlob, []string{filepath.Base(prebuiltSRPMs[srpm].SrpmPath), "PreBuilt"})
+	if res.CacheMayBeStale {
+		resultLog.Warnf("Cached RPMs for %s may be stale, its spec has a newer changelog entry than they do", baseSRPMName)
 	}
 
-	for srpm := range prebuiltDeltaSRPMS {
-		csvBlob = append(csvBlob, []string{filepath.Base(prebuiltDeltaSRPMS[srpm].SrpmPath), "PreBuiltDelta"})
+	if res.FailureType == FailureTest {
+		resultLog.Warnf("Tests failed for %s, for details see: %s", baseSRPMName, res.LogFile)
 	}
 
-	for srpm := range failedSRPMs {
-		node := failedSRPMs[srpm]
-		csvRow := []string{filepath.Base(node.SrpmPath), "Failed"}
+	if res.Node.Type == pkggraph.TypeLocalBuild && !res.Skipped && !res.UsedCache && len(res.BuiltFiles) == 0 {
+		resultLog.Warnf("Built %s, but it produced no RPMs. Its spec may be misconfigured", baseSRPMName)
+	}
 
-		// Failed nodes shouldn't have any blockers
-		blocking_nodes_str := ""
-		fromNodes := pkgGraph.From(node.ID())
-		for fromNodes.Next() {
-			fromNode := fromNodes.Node().(*pkggraph.PkgNode)
-			if _, found := failedSRPMs[fromNode.SrpmPath]; found {
-				blocking_nodes_str += filepath.Base(fromNode.SrpmPath) + "-FAIL "
-			}
-			if _, found := unbuiltSRPMs[fromNode.SrpmPath]; found {
-				blocking_nodes_str += filepath.Base(fromNode.SrpmPath) + "-UNBUILT "
-			}
+	if res.Skipped {
+		resultLog.Warnf("Skipped build for '%s' per user request. RPMs expected to be present: %v", baseSRPMName, res.BuiltFiles)
+	} else if quiet {
+		return
+	} else if res.UsedCache {
+		resultLog.Infof("Prebuilt: %s -> %v", baseSRPMName, res.BuiltFiles)
+	} else if res.Attempts > 1 {
+		resultLog.Infof("Built: %s -> %v (after %d attempts)", baseSRPMName, res.BuiltFiles, res.Attempts)
+	} else {
+		resultLog.Infof("Built: %s -> %v", baseSRPMName, res.BuiltFiles)
+	}
+}
+
+// failureLogLocation returns the log file of a failed build as "LogFile:LINE" so editors can jump to the failure.
+// Only the log file is returned if the failure line is unknown.
+func failureLogLocation(res *BuildResult) string {
+	if res.FailureLine <= 0 {
+		return res.LogFile
+	}
+	return fmt.Sprintf("%s:%d", res.LogFile, res.FailureLine)
+}
+
+// buildResultState returns the state of a build result, using the same names as RecordBuildSummary.
+func buildResultState(res *BuildResult) string {
+	switch {
+	case res.Err != nil:
+		return "Failed"
+	case res.Skipped:
+		return "Skipped"
+	case res.UsedCache && res.WasDelta:
+		return "PreBuiltDelta"
+	case res.UsedCache:
+		return "PreBuilt"
+	default:
+		return "Built"
+	}
+}
+
+// RecordBuildSummary stores the summary in to a csv. The csv is gzip compressed if outputPath ends with ".gz".
+// - options.AppendToExisting lets multiple scheduler invocations produce a single summary. Rows are deduplicated by
+// package, keeping the state from this build.
+// - Summaries written with a StateLabel can't be read by DiffBuildSummaries or CheckBuildSummaryRegressions.
+// - options.PackageLabel can be AnonymizePackageName to share the summary without disclosing the package names.
+// - The Known Issue column of a failed SRPM holds the ticket of the first of options.KnownIssues, in sorted order,
+// matching its build error. It is blank otherwise.
+// The parent directory of outputPath is created if missing. If the csv still can't be written, its contents are logged
+// instead so the results of the build are not lost. If outputPath is "-", the uncompressed csv is written to stdout.
+func RecordBuildSummary(pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, buildState *GraphBuildState, outputPath string, options SummaryCSVOptions) {
+	const traceBlockerChains = true
+	csvBlob, err := recordBuildSummary(pkgGraph, graphMutex, buildState, outputPath, traceBlockerChains, options)
+	if err != nil {
+		logger.Log.Warnf("Failed to write to CSV file '%s', logging its contents instead. Error: %s", outputPath, err)
+		logCSVRecords(csvBlob)
+	}
+}
+
+// UpdateBuildSummary stores a partial summary of a build which is still running in to a csv, overwriting any previous one.
+// It is cheap enough to be called every few seconds: the Blocker Chain column, which requires walking the graph for
+// every unbuilt SRPM, is left empty. SRPMs which have not been processed yet are reported as Unbuilt.
+// The build state is not guarded by graphMutex, so this must be called from the goroutine recording the build results.
+func UpdateBuildSummary(pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, buildState *GraphBuildState, outputPath string) {
+	const traceBlockerChains = false
+	_, err := recordBuildSummary(pkgGraph, graphMutex, buildState, outputPath, traceBlockerChains, SummaryCSVOptions{})
+	if err != nil {
+		logger.Log.Warnf("Failed to write to CSV file '%s'. Error: %s", outputPath, err)
+	}
+}
+
+// recordBuildSummary stores the summary in to a csv, see SummaryCSVOptions.
+// - traceBlockerChains fills the Blocker Chain column of unbuilt SRPMs.
+// Returns the csv records, so they can still be reported if writing them failed.
+func recordBuildSummary(pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, buildState *GraphBuildState, outputPath string, traceBlockerChains bool, options SummaryCSVOptions) (csvBlob [][]string, err error) {
+	columns := summaryColumnsOrDefault(options.Columns)
+
+	stateLabel := options.StateLabel
+	if stateLabel == nil {
+		stateLabel = func(state string) string { return state }
+	}
+
+	rows := summaryRows(pkgGraph, graphMutex, buildState, traceBlockerChains, includesSourceCounts(columns), srpmNamer(options.FullSRPMPaths))
+	annotateKnownIssues(rows, buildState, options.KnownIssues)
+	for _, row := range rows {
+		row["State"] = stateLabel(row["State"])
+	}
+
+	if options.PackageLabel != nil {
+		relabelSummaryPackages(rows, options.PackageLabel)
+	}
+
+	if options.AppendToExisting && outputPath != stdoutOutputPath {
+		rows = mergeWithExistingSummary(rows, columns, outputPath)
+	}
+
+	csvBlob = summaryCSVBlob(rows, columns)
+	err = writeCSVAtomically(csvBlob, options.Metadata.commentLines(), outputPath)
+
+	return
+}
+
+// logCSVRecords logs the csv records as a single warning, one record per line.
+func logCSVRecords(csvBlob [][]string) {
+	var csvContents strings.Builder
+	err := csv.NewWriter(&csvContents).WriteAll(csvBlob)
+	if err != nil {
+		logger.Log.Warnf("Failed to format CSV contents. Error: %s", err)
+		return
+	}
+
+	logger.Log.Warnf("CSV contents:\n%s", csvContents.String())
+}
+
+// RecordBuildSummaryPerState stores the summary in to one csv per state in outputDir, e.g. built.csv, failed.csv and
+// blocked.csv, so each state can be loaded in to its own table. A file is written for every state, even if empty.
+// - columns selects which of the SummaryColumns are written, as for RecordBuildSummary. The State column is never written.
+// - fullSRPMPaths names the SRPMs by their full path instead of their base name, as SummaryCSVOptions.FullSRPMPaths.
+// - metadata is written as a "#" comment header at the top of each file, no header is written if nil.
+func RecordBuildSummaryPerState(pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, buildState *GraphBuildState, outputDir string, columns []string, fullSRPMPaths bool, metadata *SummaryMetadata) {
+	const traceBlockerChains = true
+
+	columns = summaryColumnsOrDefault(columns)
+
+	var stateColumns []string
+	for _, column := range columns {
+		if column != "State" {
+			stateColumns = append(stateColumns, column)
 		}
+	}
 
-		csvRow = append(csvRow, blocking_nodes_str)
-		csvBlob = append(csvBlob, csvRow)
+	rowsPerState := make(map[string][]map[string]string)
+	for _, row := range summaryRows(pkgGraph, graphMutex, buildState, traceBlockerChains, includesSourceCounts(columns), srpmNamer(fullSRPMPaths)) {
+		rowsPerState[row["State"]] = append(rowsPerState[row["State"]], row)
+	}
+
+	for _, state := range summaryStates {
+		outputPath := filepath.Join(outputDir, state.fileName)
+		err := writeCSVAtomically(summaryCSVBlob(rowsPerState[state.name], stateColumns), metadata.commentLines(), outputPath)
+		if err != nil {
+			logger.Log.Warnf("Failed to write to CSV file '%s'. Error: %s", outputPath, err)
+		}
+	}
+}
+
+// summaryColumnsOrDefault returns DefaultSummaryColumns if no columns were selected, and warns about unknown columns.
+func summaryColumnsOrDefault(columns []string) []string {
+	if len(columns) == 0 {
+		return DefaultSummaryColumns
 	}
 
-	for srpm := range unbuiltSRPMs {
-		node := unbuiltSRPMs[srpm]
-		csvRow := []string{filepath.Base(node.SrpmPath), "Unbuilt"}
+	for _, column := range columns {
+		if !sliceutils.Contains(SummaryColumns, column, sliceutils.StringMatch) {
+			logger.Log.Warnf("Unknown CSV column '%s' will be left empty. Valid columns: %v", column, SummaryColumns)
+		}
+	}
+
+	return columns
+}
+
+// includesSourceCounts checks if the Patches or Sources columns were selected.
+func includesSourceCounts(columns []string) bool {
+	return sliceutils.Contains(columns, "Patches", sliceutils.StringMatch) || sliceutils.Contains(columns, "Sources", sliceutils.StringMatch)
+}
+
+// summaryRows returns a row for every SRPM of the graph, keyed by the SummaryColumns.
+// - traceBlockerChains fills the Blocker Chain column of unbuilt SRPMs.
+// - countSources fills the Patches and Sources columns, which requires querying every SRPM.
+// - srpmName names the SRPMs in the Package, Blocker and Blocker Chain columns.
+func summaryRows(pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, buildState *GraphBuildState, traceBlockerChains, countSources bool, srpmName func(node *pkggraph.PkgNode) string) (rows []map[string]string) {
+	categories := GetBuildSummary(pkgGraph, graphMutex, buildState)
+
+	graphMutex.RLock()
+	defer graphMutex.RUnlock()
 
-		blocking_nodes_str := ""
-		fromNodes := pkgGraph.From(node.ID())
-		for fromNodes.Next() {
-			fromNode := fromNodes.Node().(*pkggraph.PkgNode)
-			if _, found := failedSRPMs[fromNode.SrpmPath]; found {
-				blocking_nodes_str += filepath.Base(fromNode.SrpmPath) + "-FAIL "
+	depths := CalculateBuildDepths(pkgGraph)
+
+	addRow := func(node *pkggraph.PkgNode, state, blockers, blockerChain string) {
+		attempts, cores := "", ""
+		if res := buildState.NodeBuildResult(node); res != nil {
+			if res.Attempts > 0 {
+				attempts = strconv.Itoa(res.Attempts)
 			}
-			if _, found := unbuiltSRPMs[fromNode.SrpmPath]; found {
-				blocking_nodes_str += filepath.Base(fromNode.SrpmPath) + "-UNBUILT "
+			if res.Cores > 0 {
+				cores = strconv.Itoa(res.Cores)
 			}
 		}
 
-		csvRow = append(csvRow, blocking_nodes_str)
+		patches, sources := "", ""
+		if countSources {
+			patches, sources = srpmSourceCounts(node.SrpmPath)
+		}
+
+		version, release := nodeVersionAndRelease(node)
+		rows = append(rows, map[string]string{
+			"Package":       srpmName(node),
+			"State":         state,
+			"Blocker":       blockers,
+			"Blocker Chain": blockerChain,
+			"Architecture":  node.Architecture,
+			"Attempts":      attempts,
+			"Cache Source":  buildState.NodeCacheSource(node),
+			"Version":       version,
+			"Release":       release,
+			"Duration":      formatBuildDuration(buildState.NodeBuildDuration(node)),
+			"Cores":         cores,
+			"Patches":       patches,
+			"Sources":       sources,
+			"Depth":         strconv.Itoa(depths[node]),
+			"Queue Wait":    formatBuildDuration(queueWait(buildState.NodeBuildResult(node))),
+			"SRPM Path":     node.SrpmPath,
+		})
+	}
+
+	for _, node := range categories.Built {
+		addRow(node, "Built", "", "")
+	}
+
+	for _, node := range categories.AlreadyAvailable {
+		addRow(node, "AlreadyAvailable", "", "")
+	}
+
+	for _, node := range categories.Prebuilt {
+		addRow(node, "PreBuilt", "", "")
+	}
+
+	for _, node := range categories.PrebuiltDelta {
+		addRow(node, "PreBuiltDelta", "", "")
+	}
+
+	for _, node := range categories.Skipped {
+		addRow(node, "Skipped", "", "")
+	}
+
+	for _, node := range categories.Failed {
+		// Failed nodes shouldn't have any blockers
+		addRow(node, "Failed", csvBlockers(pkgGraph, node, categories, srpmName), "")
+	}
+
+	for _, node := range categories.Unbuilt {
+		blockerChain := ""
+		if traceBlockerChains {
+			blockerChain = formatBlockingPaths(TraceBlockingRoot(pkgGraph, node, buildState), srpmName)
+		}
+		addRow(node, "Unbuilt", csvBlockers(pkgGraph, node, categories, srpmName), blockerChain)
+	}
+
+	return
+}
+
+// summaryCSVBlob returns the csv records of the rows, with a header of the selected columns.
+func summaryCSVBlob(rows []map[string]string, columns []string) (csvBlob [][]string) {
+	// Sort the rows so the file is stable between runs, regardless of the selected columns.
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i]["Package"] != rows[j]["Package"] {
+			return rows[i]["Package"] < rows[j]["Package"]
+		}
+		return rows[i]["State"] < rows[j]["State"]
+	})
+
+	csvBlob = [][]string{columns}
+	for _, row := range rows {
+		csvRow := make([]string, 0, len(columns))
+		for _, column := range columns {
+			csvRow = append(csvRow, row[column])
+		}
 		csvBlob = append(csvBlob, csvRow)
 	}
 
-	csvFile, err := os.Create(outputPath)
+	return
+}
+
+// mergeWithExistingSummary adds the rows of the csv stored at outputPath to rows, if it has the same columns.
+// Packages found in both keep the row from rows. If the csv's package column was not selected, all of its rows are kept.
+func mergeWithExistingSummary(rows []map[string]string, columns []string, outputPath string) (mergedRows []map[string]string) {
+	mergedRows = rows
+
+	if _, err := os.Stat(outputPath); os.IsNotExist(err) {
+		return
+	}
+
+	records, err := readCSVRecords(outputPath)
+	if err != nil {
+		logger.Log.Warnf("Failed to read the existing CSV file '%s', it will be overwritten. Error: %s", outputPath, err)
+		return
+	}
+
+	if len(records) == 0 || !reflect.DeepEqual(records[0], columns) {
+		logger.Log.Warnf("The existing CSV file '%s' has different columns, it will be overwritten", outputPath)
+		return
+	}
+
+	newPackages := make(map[string]bool)
+	for _, row := range rows {
+		newPackages[row["Package"]] = true
+	}
+
+	for _, record := range records[1:] {
+		row := make(map[string]string)
+		for i, column := range columns {
+			row[column] = record[i]
+		}
+
+		if !newPackages[row["Package"]] || row["Package"] == "" {
+			mergedRows = append(mergedRows, row)
+		}
+	}
+
+	return
+}
+
+// nodeVersionAndRelease splits the "version-release" string the graph stores for a node's package.
+// The release is empty if the graph only recorded a version.
+func nodeVersionAndRelease(node *pkggraph.PkgNode) (version, release string) {
+	version = node.VersionedPkg.Version
+	if separatorIndex := strings.LastIndex(version, "-"); separatorIndex != -1 {
+		version, release = version[:separatorIndex], version[separatorIndex+1:]
+	}
+
+	return
+}
+
+// readCSVRecords reads all records of a csv file, decompressing it first if csvPath ends with ".gz".
+func readCSVRecords(csvPath string) (records [][]string, err error) {
+	csvFile, err := os.Open(csvPath)
 	if err != nil {
-		logger.Log.Warnf("Unable to create '%s' file. Error: %s", outputPath, err)
 		return
 	}
 	defer csvFile.Close()
 
-	csvWriter := csv.NewWriter(csvFile)
-	err = csvWriter.WriteAll(csvBlob)
+	var csvInput io.Reader = csvFile
+	if strings.HasSuffix(csvPath, gzipExtension) {
+		gzipReader, gzipErr := gzip.NewReader(csvFile)
+		if gzipErr != nil {
+			return nil, gzipErr
+		}
+		defer gzipReader.Close()
+		csvInput = gzipReader
+	}
+
+	// Skip the metadata header written by RecordBuildSummary.
+	csvReader := csv.NewReader(csvInput)
+	csvReader.Comment = '#'
+
+	return csvReader.ReadAll()
+}
+
+// writeReport writes a report to outputPath, or to stdout if outputPath is stdoutOutputPath.
+func writeReport(report, outputPath string) (err error) {
+	if outputPath == stdoutOutputPath {
+		_, err = io.WriteString(summaryStdout, report)
+		return
+	}
+
+	return file.Write(report, outputPath)
+}
+
+// writeCSVAtomically writes the CSV records to a temporary file next to outputPath and renames it into place
+// once all records were written, so readers never see a partially written file. The records are gzip compressed if
+// outputPath ends with ".gz". Each of commentLines is written ahead of the records, prefixed with "# ".
+// The parent directory of outputPath is created if missing.
+// On failure the temporary file is removed and any previous file at outputPath is left untouched.
+// If outputPath is stdoutOutputPath, the uncompressed records are written to stdout instead.
+func writeCSVAtomically(csvBlob [][]string, commentLines []string, outputPath string) (err error) {
+	const (
+		csvFilePerms   = 0644
+		outputDirPerms = 0755
+	)
+
+	if outputPath == stdoutOutputPath {
+		return writeCSVRecords(summaryStdout, csvBlob, commentLines)
+	}
+
+	err = os.MkdirAll(filepath.Dir(outputPath), outputDirPerms)
 	if err != nil {
-		logger.Log.Warnf("Failed to write to CSV file '%s'. Error: %s", outputPath, err)
+		return
+	}
+
+	csvFile, err := os.CreateTemp(filepath.Dir(outputPath), filepath.Base(outputPath)+".tmp-*")
+	if err != nil {
+		return
+	}
+	tempPath := csvFile.Name()
+
+	defer func() {
+		if err != nil {
+			csvFile.Close()
+			os.Remove(tempPath)
+		}
+	}()
+
+	if strings.HasSuffix(outputPath, gzipExtension) {
+		gzipWriter := gzip.NewWriter(csvFile)
+		err = writeCSVRecords(gzipWriter, csvBlob, commentLines)
+		if err != nil {
+			return
+		}
+
+		// Closing the gzip writer flushes the compressed stream, it must happen before the file is closed.
+		err = gzipWriter.Close()
+	} else {
+		err = writeCSVRecords(csvFile, csvBlob, commentLines)
+	}
+	if err != nil {
+		return
+	}
+
+	// os.CreateTemp creates the file with 0600 permissions, match what os.Create would have produced.
+	err = csvFile.Chmod(csvFilePerms)
+	if err != nil {
+		return
 	}
+
+	err = csvFile.Close()
+	if err != nil {
+		return
+	}
+
+	return os.Rename(tempPath, outputPath)
+}
+
+// writeCSVRecords writes the comment lines, prefixed with "# ", followed by the CSV records.
+func writeCSVRecords(out io.Writer, csvBlob [][]string, commentLines []string) (err error) {
+	for _, line := range commentLines {
+		_, err = fmt.Fprintf(out, "# %s\n", line)
+		if err != nil {
+			return
+		}
+	}
+
+	return csv.NewWriter(out).WriteAll(csvBlob)
 }
 
 // PrintBuildSummary prints the summary of the entire build to the logger.
-func PrintBuildSummary(pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, buildState *GraphBuildState, allowToolchainRebuilds bool) {
+// Toolchain conflicts are logged as errors if they are fatal, and detailed sections are only logged in debug mode.
+// Toolchain conflicts are always reported, regardless of options.PackageFilter.
+// If stderr is a terminal and NO_COLOR is not set, built, prebuilt and failed SRPMs are highlighted in color.
+func PrintBuildSummary(pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, buildState *GraphBuildState, allowToolchainRebuilds bool, options SummaryOptions) {
+	writers := summaryWriters{
+		info:           newLogWriter(logger.Log.Info),
+		warnings:       newLogWriter(logger.Log.Warn),
+		conflicts:      newLogWriter(logger.Log.Info),
+		fatalConflicts: newLogWriter(logger.Log.Error),
+		colors:         useSummaryColors(),
+	}
+	if logger.Log.IsLevelEnabled(logrus.DebugLevel) {
+		writers.verbose = newLogWriter(logger.Log.Debug)
+	}
+
+	printBuildSummary(writers, pkgGraph, graphMutex, buildState, allowToolchainRebuilds, options)
+}
+
+// PrintBuildSummaryTo writes the summary of the entire build to w, one entry per line.
+// Detailed sections are only included if the logger's debug level is enabled, since some of them are expensive to generate.
+func PrintBuildSummaryTo(w io.Writer, pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, buildState *GraphBuildState, allowToolchainRebuilds bool, options SummaryOptions) {
+	writers := summaryWriters{
+		info:           w,
+		warnings:       w,
+		conflicts:      w,
+		fatalConflicts: w,
+	}
+	if logger.Log.IsLevelEnabled(logrus.DebugLevel) {
+		writers.verbose = w
+	}
+
+	printBuildSummary(writers, pkgGraph, graphMutex, buildState, allowToolchainRebuilds, options)
+}
+
+// PrintBuildCounts writes the build counts to w as a single line of space separated key=value pairs, for example:
+// "built=120 already_available=0 prebuilt=30 prebuilt_delta=0 skipped=0 failed=2 blocked=5 unresolved=0 conflicts=0".
+// As in the full summary, conflicts counts both the toolchain RPM and SRPM conflicts.
+func PrintBuildCounts(w io.Writer, pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, buildState *GraphBuildState) {
+	// The counts don't depend on whether conflicts or unresolved dependencies are fatal.
+	const (
+		allowToolchainRebuilds = false
+		strictUnresolved       = false
+	)
+	status := CalculateBuildStatus(pkgGraph, graphMutex, buildState, allowToolchainRebuilds, strictUnresolved)
+
+	writeSummaryLine(w, "built=%d already_available=%d prebuilt=%d prebuilt_delta=%d skipped=%d failed=%d blocked=%d unresolved=%d conflicts=%d",
+		status.BuiltCount, status.AlreadyAvailableCount, status.PrebuiltCount, status.PrebuiltDeltaCount, status.SkippedCount,
+		status.FailedCount, status.BlockedCount, status.UnresolvedCount, status.RPMConflictCount+status.SRPMConflictCount)
+}
+
+// printBuildSummary writes the summary of the entire build to the provided writers.
+func printBuildSummary(writers summaryWriters, pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, buildState *GraphBuildState, allowToolchainRebuilds bool, options SummaryOptions) {
+	writers.fullSRPMPaths = options.FullSRPMPaths
+	categories := GetBuildSummary(pkgGraph, graphMutex, buildState)
+
 	graphMutex.RLock()
 	defer graphMutex.RUnlock()
 
-	failedSRPMs := make(map[string]bool)
-	failures := buildState.BuildFailures()
-	for _, failure := range failures {
-		failedSRPMs[failure.Node.SrpmPath] = true
+	if len(options.PackageFilter) != 0 {
+		categories = filterBuildNodeCategories(pkgGraph, categories, options.PackageFilter)
 	}
+	if options.ExcludeToolchain {
+		categories = excludeToolchainCategories(pkgGraph, categories, buildState)
+	}
+	status := buildStatusFromCategories(categories, buildState, allowToolchainRebuilds, options.StrictUnresolved)
+	// The sections walking the graph instead of the categories list the same SRPMs as the categories.
+	filter := newSummaryFilter(pkgGraph, buildState, options)
 
-	prebuiltSRPMs := make(map[string]bool)
-	prebuiltDeltaSRPMS := make(map[string]bool)
-	builtSRPMs := make(map[string]bool)
-	unbuiltSRPMs := make(map[string]bool)
-	unresolvedDependencies := make(map[string]bool)
 	rpmConflicts := buildState.ConflictingRPMs()
 	srpmConflicts := buildState.ConflictingSRPMs()
 
-	conflictsLogger := logger.Log.Errorf
-	if allowToolchainRebuilds || (len(rpmConflicts) == 0 && len(srpmConflicts) == 0) {
-		conflictsLogger = logger.Log.Infof
+	if status.HasFatalConflicts {
+		writers.conflicts = writers.fatalConflicts
 	}
 
-	buildNodes := pkgGraph.AllBuildNodes()
-	for _, node := range buildNodes {
-		if buildState.IsNodeCached(node) {
-			if buildState.IsNodeDelta(node) {
-				prebuiltDeltaSRPMS[node.SrpmPath] = true
-			} else {
-				prebuiltSRPMs[node.SrpmPath] = true
-			}
-			continue
-		} else if buildState.IsNodeAvailable(node) {
-			builtSRPMs[node.SrpmPath] = true
-			continue
-		}
+	writeSummaryLine(writers.info, "---------------------------")
+	writeSummaryLine(writers.info, "--------- Summary ---------")
+	writeSummaryLine(writers.info, "---------------------------")
 
-		_, found := failedSRPMs[node.SrpmPath]
-		if !found {
-			unbuiltSRPMs[node.SrpmPath] = true
+	// Percentages are relative to every SRPM of the build, each SRPM is counted in exactly one of these categories.
+	totalSRPMs := status.BuiltCount + status.AlreadyAvailableCount + status.PrebuiltCount + status.PrebuiltDeltaCount +
+		status.SkippedCount + status.FailedCount + status.BlockedCount
+
+	writeSummaryLine(writers.info, writers.colorize(colorGreen, "Number of built SRPMs:             %d (%s)"), status.BuiltCount, formatPercentage(status.BuiltCount, totalSRPMs))
+	writeSummaryLine(writers.info, "Number of already available SRPMs: %d (%s)", status.AlreadyAvailableCount, formatPercentage(status.AlreadyAvailableCount, totalSRPMs))
+	writeSummaryLine(writers.info, writers.colorize(colorYellow, "Number of prebuilt SRPMs:          %d (%s)"), status.PrebuiltCount, formatPercentage(status.PrebuiltCount, totalSRPMs))
+	writeSummaryLine(writers.info, writers.colorize(colorYellow, "Number of prebuilt delta SRPMs:    %d (%s)"), status.PrebuiltDeltaCount, formatPercentage(status.PrebuiltDeltaCount, totalSRPMs))
+	writeSummaryLine(writers.info, "--> delta RPMs skipped (rebuilt):  %d", status.DeltaSkippedCount)
+	writeSummaryLine(writers.info, writers.colorize(colorYellow, "Number of skipped SRPMs:           %d (%s)"), status.SkippedCount, formatPercentage(status.SkippedCount, totalSRPMs))
+	writeSummaryLine(writers.info, writers.colorize(colorRed, "Number of failed SRPMs:            %d (%s)"), status.FailedCount, formatPercentage(status.FailedCount, totalSRPMs))
+	writeSummaryLine(writers.info, writers.colorize(colorRed, "Number of timed-out SRPMs:         %d"), status.TimedOutCount)
+	writeSummaryLine(writers.info, "Number of SRPMs with failed tests:  %d", status.TestFailedCount)
+	writeSummaryLine(writers.info, "Number of built SRPMs without RPMs: %d", status.NoRPMsBuiltCount)
+	if status.BuiltCount != 0 {
+		writeSummaryLine(writers.info, "Produced %d RPMs from %d SRPMs (avg %.1f)", status.BuiltRPMCount, status.BuiltCount, float64(status.BuiltRPMCount)/float64(status.BuiltCount))
+	}
+	writeSummaryLine(writers.info, writers.colorize(colorRed, "Number of blocked SRPMs:           %d (%s)"), status.BlockedCount, formatPercentage(status.BlockedCount, totalSRPMs))
+	writeSummaryLine(writers.info, "Number of unresolved dependencies: %d", status.UnresolvedCount)
+	writeSummaryLine(writers.info, "Cache hit rate: %.1f%%", categories.CacheHitRate()*100)
+
+	if !options.BuildStartTime.IsZero() {
+		wallClock := time.Since(options.BuildStartTime)
+		cumulative := cumulativeBuildDuration(categories, buildState)
+		writeSummaryLine(writers.info, "Wall clock: %s, Cumulative: %s, Parallel efficiency: %.2f", wallClock.Round(time.Second), cumulative.Round(time.Second), cumulative.Seconds()/wallClock.Seconds())
+
+		if options.ExpectedDuration > 0 && wallClock > options.ExpectedDuration {
+			writeSummaryLine(writers.warnings, "Build exceeded its time budget of %s by %s, longest builds on the critical path:", options.ExpectedDuration, (wallClock - options.ExpectedDuration).Round(time.Second))
+			for _, node := range overBudgetContributors(pkgGraph, buildState, filter, slowestBuildsToList) {
+				writeSummaryLine(writers.warnings, "--> %s (%s)", writers.srpmName(node), formatBuildDuration(buildState.NodeBuildDuration(node)))
+			}
 		}
 	}
 
-	for _, node := range pkgGraph.AllRunNodes() {
-		if node.State == pkggraph.StateUnresolved {
-			unresolvedDependencies[node.VersionedPkg.String()] = true
+	concurrency := calculateBuildConcurrency(categories, buildState)
+	if concurrency.peak != 0 {
+		writeSummaryLine(writers.info, "Build concurrency: peak %d, average %.1f", concurrency.peak, concurrency.average)
+		for running := 0; running <= concurrency.peak; running++ {
+			if duration := concurrency.histogram[running]; duration != 0 {
+				writeSummaryLine(writers.info, "--> %d running: %s", running, duration.Round(time.Second))
+			}
 		}
 	}
 
-	logger.Log.Info("---------------------------")
-	logger.Log.Info("--------- Summary ---------")
-	logger.Log.Info("---------------------------")
+	averageCores, coreBuilds := averageAllocatedCores(categories, buildState)
+	if coreBuilds != 0 {
+		writeSummaryLine(writers.info, "Average cores allocated per build: %.1f (over %d builds)", averageCores, coreBuilds)
+	}
 
-	logger.Log.Infof("Number of built SRPMs:             %d", len(builtSRPMs))
-	logger.Log.Infof("Number of prebuilt SRPMs:          %d", len(prebuiltSRPMs))
-	logger.Log.Infof("Number of prebuilt delta SRPMs:    %d", len(prebuiltDeltaSRPMS))
-	logger.Log.Infof("Number of failed SRPMs:            %d", len(failures))
-	logger.Log.Infof("Number of blocked SRPMs:           %d", len(unbuiltSRPMs))
-	logger.Log.Infof("Number of unresolved dependencies: %d", len(unresolvedDependencies))
+	archCounts := countByArchitecture(categories)
+	if len(archCounts) != 0 {
+		writeSummaryLine(writers.info, "Number of SRPMs per architecture:")
+	}
+	for _, arch := range sortedArchitectures(archCounts) {
+		counts := archCounts[arch]
+		writeSummaryLine(writers.info, "--> %s: %d built, %d prebuilt, %d prebuilt delta, %d skipped, %d failed, %d blocked", arch, counts.built, counts.prebuilt, counts.prebuiltDelta, counts.skipped, counts.failed, counts.blocked)
+	}
 
-	if allowToolchainRebuilds && (len(rpmConflicts) > 0 || len(srpmConflicts) > 0) {
-		logger.Log.Infof("Toolchain RPMs conflicts are ignored since ALLOW_TOOLCHAIN_REBUILDS=y")
+	if allowToolchainRebuilds && status.HasConflicts {
+		writeSummaryLine(writers.info, "Toolchain RPMs conflicts are ignored since ALLOW_TOOLCHAIN_REBUILDS=y")
 	}
 
-	if len(rpmConflicts) > 0 || len(srpmConflicts) > 0 {
-		conflictsLogger("Number of toolchain RPM conflicts: %d", len(rpmConflicts))
-		conflictsLogger("Number of toolchain SRPM conflicts: %d", len(srpmConflicts))
+	if status.HasConflicts {
+		writeSummaryLine(writers.conflicts, "Number of toolchain RPM conflicts: %d", status.RPMConflictCount)
+		writeSummaryLine(writers.conflicts, "Number of toolchain SRPM conflicts: %d", status.SRPMConflictCount)
 	}
 
-	if len(builtSRPMs) != 0 {
-		logger.Log.Info("Built SRPMs:")
-		for srpm := range builtSRPMs {
-			logger.Log.Infof("--> %s", filepath.Base(srpm))
+	// The counts and toolchain conflicts are always printed, the listings depend on the verbosity.
+	allWriters := writers
+	writers = allWriters.forVerbosity(options.Verbosity, SummaryVerbosityFull)
+
+	if len(categories.Built) != 0 {
+		writeSummaryLine(writers.info, "Built SRPMs:")
+		for _, node := range sortedNodes(categories.Built) {
+			version, release := nodeVersionAndRelease(node)
+			if release == "" {
+				writeSummaryLine(writers.info, writers.colorize(colorGreen, "--> %s (version: %s)"), writers.srpmName(node), version)
+			} else {
+				writeSummaryLine(writers.info, writers.colorize(colorGreen, "--> %s (version: %s, release: %s)"), writers.srpmName(node), version, release)
+			}
 		}
 	}
 
-	if len(prebuiltSRPMs) != 0 {
-		logger.Log.Info("Prebuilt SRPMs:")
-		for srpm := range prebuiltSRPMs {
-			logger.Log.Infof("--> %s", filepath.Base(srpm))
+	emptyBuilds := builtWithoutRPMs(categories.Built, buildState)
+	if len(emptyBuilds) != 0 {
+		writeSummaryLine(writers.info, "Built but produced no RPMs (i.e., the spec may be misconfigured):")
+		for _, node := range emptyBuilds {
+			writeSummaryLine(writers.info, "--> %s", writers.srpmName(node))
 		}
 	}
 
-	if len(prebuiltDeltaSRPMS) != 0 {
-		logger.Log.Info("Skipped SRPMs (i.e., delta mode is on, packages are already available in a repo):")
-		for srpm := range prebuiltDeltaSRPMS {
-			logger.Log.Infof("--> %s", filepath.Base(srpm))
+	slowestBuilds := slowestBuiltNodes(categories.Built, buildState, slowestBuildsToList)
+	if len(slowestBuilds) != 0 {
+		writeSummaryLine(writers.info, "Slowest %d built SRPMs:", len(slowestBuilds))
+		for _, node := range slowestBuilds {
+			writeSummaryLine(writers.info, "--> %s (%s)", writers.srpmName(node), formatBuildDuration(buildState.NodeBuildDuration(node)))
 		}
 	}
 
-	if len(failures) != 0 {
-		logger.Log.Info("Failed SRPMs:")
-		for _, failure := range failures {
-			logger.Log.Infof("--> %s , error: %s, for details see: %s", failure.Node.SRPMFileName(), failure.Err, failure.LogFile)
+	memoryHungryResults := memoryHungryBuilds(categories, buildState, memoryHungryBuildsToList)
+	if len(memoryHungryResults) != 0 {
+		writeSummaryLine(writers.info, "Most memory-hungry %d SRPM builds (by peak RSS):", len(memoryHungryResults))
+		for _, res := range memoryHungryResults {
+			writeSummaryLine(writers.info, "--> %s (%s)", writers.srpmName(res.Node), formatPeakRSS(res.PeakRSS))
 		}
 	}
 
-	if len(unbuiltSRPMs) != 0 {
-		logger.Log.Info("Blocked SRPMs:")
-		for srpm := range unbuiltSRPMs {
-			logger.Log.Infof("--> %s", filepath.Base(srpm))
+	longestWaits, averageWait := longestQueueWaits(categories, buildState, longestQueueWaitsToList)
+	if len(longestWaits) != 0 {
+		writeSummaryLine(writers.info, "Average queue wait: %s", averageWait.Round(time.Second))
+		writeSummaryLine(writers.info, "Longest %d queue waits (i.e., waiting for a free worker):", len(longestWaits))
+		for _, res := range longestWaits {
+			writeSummaryLine(writers.info, "--> %s (%s)", writers.srpmName(res.Node), queueWait(res).Round(time.Second))
 		}
 	}
 
-	if len(unresolvedDependencies) != 0 {
-		logger.Log.Info("Unresolved dependencies:")
-		for dependency := range unresolvedDependencies {
-			logger.Log.Infof("--> %s", dependency)
-		}
+	if startedBuilds := timelineResults(categories, buildState); len(startedBuilds) != 0 {
+		first, last := startedBuilds[0], startedBuilds[len(startedBuilds)-1]
+		writeSummaryLine(writers.info, "First build started: %s at %s", writers.srpmName(first.Node), first.StartTime.Format(timelineTimeFormat))
+		writeSummaryLine(writers.info, "Last build started:  %s at %s", writers.srpmName(last.Node), last.StartTime.Format(timelineTimeFormat))
 	}
 
-	if len(rpmConflicts) != 0 {
-		conflictsLogger("RPM conflicts with toolchain: ")
-		for _, conflict := range rpmConflicts {
-			conflictsLogger("--> %s", conflict)
+	// The total is the length of the whole critical path, even if some of its SRPMs are filtered out of the listing.
+	criticalPath, criticalPathDuration := CalculateCriticalPath(pkgGraph, buildState)
+	criticalPath = sliceutils.FindMatches(criticalPath, filter.includes)
+	if len(criticalPath) != 0 {
+		writeSummaryLine(writers.info, "Critical path: %s (total %s)", formatCriticalPath(criticalPath, writers.srpmName), formatBuildDuration(criticalPathDuration))
+	}
+
+	if options.IncludeOutputSizes {
+		rpmSizes := builtRPMSizes(categories.Built, buildState)
+
+		var totalSize int64
+		for _, rpmSize := range rpmSizes {
+			totalSize += rpmSize.size
+		}
+		writeSummaryLine(writers.info, "Total output size: %s in %d RPMs", formatByteSize(totalSize), len(rpmSizes))
+
+		if len(rpmSizes) > largestRPMsToList {
+			rpmSizes = rpmSizes[:largestRPMsToList]
+		}
+		if len(rpmSizes) != 0 {
+			writeSummaryLine(writers.info, "Largest %d built RPMs:", len(rpmSizes))
+			for _, rpmSize := range rpmSizes {
+				writeSummaryLine(writers.info, "--> %s (%s)", filepath.Base(rpmSize.path), formatByteSize(rpmSize.size))
+			}
 		}
 	}
 
-	if len(srpmConflicts) != 0 {
-		conflictsLogger("SRPM conflicts with toolchain: ")
-		for _, conflict := range srpmConflicts {
-			conflictsLogger("--> %s", conflict)
+	retriedBuilds := retriedBuiltNodes(categories.Built, buildState)
+	if len(retriedBuilds) != 0 {
+		writeSummaryLine(writers.info, "SRPMs built only after retries (i.e., possibly flaky builds):")
+		for _, node := range retriedBuilds {
+			writeSummaryLine(writers.info, "--> %s (%d attempts)", writers.srpmName(node), buildState.NodeBuildResult(node).Attempts)
 		}
 	}
+
+	cacheMisses := groupByCacheMissReason(categories.Built, buildState)
+	if len(cacheMisses) != 0 {
+		writeSummaryLine(writers.info, "Cache miss reasons:")
+		for reason := CacheMissNone + 1; reason <= CacheMissRPMsAbsent; reason++ {
+			nodes := cacheMisses[reason]
+			if len(nodes) == 0 {
+				continue
+			}
+
+			writeSummaryLine(writers.info, "--> %s: %d", reason, len(nodes))
+			if writers.verbose == nil {
+				continue
+			}
+			for _, node := range nodes {
+				writeSummaryLine(writers.verbose, "----> %s", writers.srpmName(node))
+			}
+		}
+	}
+
+	forcedBuilds := forcedRebuilds(categories.Built, buildState)
+	if len(forcedBuilds) != 0 {
+		var forcedDuration time.Duration
+		for _, node := range forcedBuilds {
+			forcedDuration += buildState.NodeBuildDuration(node)
+		}
+
+		writeSummaryLine(writers.info, "Forced rebuilds (i.e., built despite a valid cache entry, %s total):", forcedDuration.Round(time.Second))
+		for _, node := range forcedBuilds {
+			writeSummaryLine(writers.info, "--> %s (%s, reason: %s)", writers.srpmName(node), buildState.NodeBuildDuration(node).Round(time.Second), buildState.NodeCacheMissReason(node))
+		}
+	}
+
+	if len(categories.Prebuilt) != 0 {
+		writeSummaryLine(writers.info, "Prebuilt SRPMs (i.e., restored from the local cache):")
+		for _, node := range sortedNodes(categories.Prebuilt) {
+			writeSummaryLine(writers.info, writers.colorize(colorYellow, "--> %s (from: %s)"), writers.srpmName(node), buildState.NodeCacheSource(node))
+		}
+	}
+
+	if len(categories.AlreadyAvailable) != 0 {
+		writeSummaryLine(writers.info, "Already available SRPMs (i.e., not built or restored from the cache by this build):")
+		for _, node := range sortedNodes(categories.AlreadyAvailable) {
+			writeSummaryLine(writers.info, "--> %s", writers.srpmName(node))
+		}
+	}
+
+	if len(categories.PrebuiltDelta) != 0 {
+		writeSummaryLine(writers.info, "Delta-applied SRPMs (i.e., delta mode is on, the delta RPMs pulled from a remote repo were used instead of building):")
+		for _, node := range sortedNodes(categories.PrebuiltDelta) {
+			writeSummaryLine(writers.info, writers.colorize(colorYellow, "--> %s (repo package: %s, from: %s)"), writers.srpmName(node), filepath.Base(node.RpmPath), buildState.NodeCacheSource(node))
+		}
+	}
+
+	deltaSkipped := deltaSkippedNodes(categories.Built, buildState)
+	if len(deltaSkipped) != 0 {
+		writeSummaryLine(writers.info, "Delta-skipped SRPMs (i.e., delta mode is on, but the SRPMs were built instead of using delta RPMs):")
+		for _, node := range deltaSkipped {
+			writeSummaryLine(writers.info, "--> %s", writers.srpmName(node))
+		}
+	}
+
+	var staleCachedNodes []*pkggraph.PkgNode
+	for _, node := range sortedNodes(categories.PrebuiltDelta) {
+		if buildState.IsNodeCacheStale(node) {
+			staleCachedNodes = append(staleCachedNodes, node)
+		}
+	}
+	if len(staleCachedNodes) != 0 {
+		writeSummaryLine(writers.info, "Potentially stale cached packages (i.e., the spec has a newer changelog entry than the cached RPMs):")
+		for _, node := range staleCachedNodes {
+			writeSummaryLine(writers.info, "--> %s", writers.srpmName(node))
+		}
+	}
+
+	if len(categories.Skipped) != 0 {
+		writeSummaryLine(writers.info, "Skipped SRPMs (i.e., marked to be skipped per user request):")
+		for _, node := range sortedNodes(categories.Skipped) {
+			writeSummaryLine(writers.info, writers.colorize(colorYellow, "--> %s"), writers.srpmName(node))
+		}
+	}
+
+	writers = allWriters.forVerbosity(options.Verbosity, SummaryVerbosityFailures)
+
+	if len(categories.Failures) != 0 {
+		writeSummaryLine(writers.info, "Failed SRPMs:")
+		failures := sortedFailures(categories.Failures)
+		unlistedFailures := 0
+		if options.MaxFailuresListed > 0 && len(failures) > options.MaxFailuresListed {
+			unlistedFailures = len(failures) - options.MaxFailuresListed
+			failures = failures[:options.MaxFailuresListed]
+		}
+		for _, failure := range failures {
+			writeSummaryLine(writers.info, writers.colorize(colorRed, "--> %s , error: %s, for details see: %s"), writers.srpmName(failure.Node), failure.Err, failure.LogFile)
+		}
+		if unlistedFailures != 0 {
+			writeSummaryLine(writers.info, "... and %d more", unlistedFailures)
+		}
+		writeSummaryLine(writers.info, "Failure reasons: %s", formatFailureReasonHistogram(failureReasonHistogram(categories.Failures)))
+		writeSummaryLine(writers.info, "Distinct failure reasons: %d", countDistinctErrorMessages(categories.Failures))
+	}
+
+	var failuresWithAlternatives []*BuildResult
+	for _, failure := range sortedFailures(categories.Failures) {
+		if len(options.PrebuiltAlternatives[failure.Node.SRPMFileName()]) != 0 {
+			failuresWithAlternatives = append(failuresWithAlternatives, failure)
+		}
+	}
+	if len(failuresWithAlternatives) != 0 {
+		writeSummaryLine(writers.info, "Failed SRPMs with prebuilt alternatives (i.e., matching RPMs are available in the configured repos):")
+		for _, failure := range failuresWithAlternatives {
+			alternatives := options.PrebuiltAlternatives[failure.Node.SRPMFileName()]
+			writeSummaryLine(writers.info, "--> %s (prebuilt available: %s)", writers.srpmName(failure.Node), strings.Join(alternatives, ", "))
+		}
+	}
+
+	nonBlocking, builtDependents := nonBlockingFailures(pkgGraph, categories, writers.srpmName)
+	if len(nonBlocking) != 0 {
+		writeSummaryLine(writers.info, "Non-blocking failures (i.e., failed SRPMs whose dependents still built):")
+		for _, failure := range nonBlocking {
+			writeSummaryLine(writers.info, "--> %s (built dependents: %s)", writers.srpmName(failure.Node), strings.Join(builtDependents[failure], ", "))
+		}
+	}
+
+	var blockingFailures []failureImpact
+	for _, impact := range failuresByImpact(pkgGraph, categories.Failures, buildState) {
+		if impact.blockedSRPMs > 0 {
+			blockingFailures = append(blockingFailures, impact)
+		}
+	}
+	if len(blockingFailures) != 0 {
+		writeSummaryLine(writers.info, "Failed SRPMs by number of SRPMs they block (i.e., fixing the first ones unblocks the most packages):")
+		for _, impact := range blockingFailures {
+			writeSummaryLine(writers.info, "--> %s (blocks %d SRPMs)", writers.srpmName(impact.failure.Node), impact.blockedSRPMs)
+		}
+	}
+
+	timedOutFailures := timedOutBuilds(categories.Failures)
+	if len(timedOutFailures) != 0 {
+		writeSummaryLine(writers.info, "Timed-out SRPMs (i.e., the build was killed after exceeding the timeout):")
+		for _, failure := range timedOutFailures {
+			writeSummaryLine(writers.info, "--> %s , for details see: %s", writers.srpmName(failure.Node), failure.LogFile)
+		}
+	}
+
+	testFailures := nodesWithFailureType(categories.Built, buildState, FailureTest)
+	if len(testFailures) != 0 {
+		writeSummaryLine(writers.info, "SRPMs with failed tests (i.e., the package built, but its %%check section failed):")
+		for _, node := range testFailures {
+			writeSummaryLine(writers.info, "--> %s , for details see: %s", writers.srpmName(node), buildState.NodeBuildResult(node).LogFile)
+		}
+	}
+
+	if len(categories.Unbuilt) != 0 {
+		writeSummaryLine(writers.info, "Blocked SRPMs:")
+		blockingCausesCache := make(map[*pkggraph.PkgNode]blockingCauses)
+		for _, node := range sortedNodes(categories.Unbuilt) {
+			causes := findBlockingCauses(pkgGraph, node, categories, blockingCausesCache)
+			writeSummaryLine(writers.info, writers.colorize(colorRed, "--> %s (blocked by %s)"), writers.srpmName(node), causes)
+		}
+	}
+
+	// Tracing the blockers walks the graph for every blocked SRPM, only do it when the output will actually be used.
+	if len(categories.Unbuilt) != 0 && writers.verbose != nil {
+		writeSummaryLine(writers.verbose, "Blocked SRPMs and the failures blocking them:")
+		for _, node := range sortedNodes(categories.Unbuilt) {
+			writeSummaryLine(writers.verbose, "--> %s", formatBlockingPaths(TraceBlockingRoot(pkgGraph, node, buildState), writers.srpmName))
+		}
+	}
+
+	writers = allWriters.forVerbosity(options.Verbosity, SummaryVerbosityFull)
+
+	var cycles [][]*pkggraph.PkgNode
+	for _, cycle := range DetectCycles(pkgGraph) {
+		if len(sliceutils.FindMatches(cycle, filter.includes)) != 0 {
+			cycles = append(cycles, cycle)
+		}
+	}
+	if len(cycles) != 0 {
+		writeSummaryLine(writers.info, "Dependency cycles detected:")
+		for _, cycle := range cycles {
+			writeSummaryLine(writers.info, "--> %s", formatCycle(cycle, writers.srpmName))
+		}
+	}
+
+	writers = allWriters.forVerbosity(options.Verbosity, SummaryVerbosityFailures)
+
+	if len(categories.Unresolved) != 0 {
+		unresolvedWriter, unresolvedTitle := writers.info, "Unresolved dependencies:"
+		if status.HasFatalUnresolved {
+			unresolvedWriter, unresolvedTitle = writers.fatalConflicts, "Unresolved dependencies (i.e., treated as build failures):"
+		}
+		writeSummaryLine(unresolvedWriter, unresolvedTitle)
+		unresolvedDependencies := sliceutils.SetToSlice(categories.Unresolved)
+		sort.Strings(unresolvedDependencies)
+
+		// Listing the consumers of every unresolved dependency can be very long, only do so in verbose mode.
+		var consumers map[string][]string
+		if writers.verbose != nil {
+			consumers = unresolvedDependencyConsumers(pkgGraph, writers.srpmName)
+		}
+
+		for _, dependency := range unresolvedDependencies {
+			writeSummaryLine(unresolvedWriter, "--> %s", dependency)
+			for _, srpm := range consumers[dependency] {
+				writeSummaryLine(writers.verbose, "----> needed by %s", srpm)
+			}
+		}
+	}
+
+	writers = allWriters.forVerbosity(options.Verbosity, SummaryVerbosityFull)
+
+	externalDependencies := externalRuntimeDependencies(pkgGraph, filter)
+	if len(externalDependencies) != 0 {
+		writeSummaryLine(writers.info, "External runtime dependencies (i.e., consumed from a repo instead of built):")
+		for _, dependency := range externalDependencies {
+			writeSummaryLine(writers.info, "--> %s", dependency)
+		}
+	}
+
+	conflictingProviders := conflictingRPMProviders(pkgGraph, buildState, writers.srpmName, filter.includes)
+	if len(conflictingProviders) != 0 {
+		writeSummaryLine(writers.info, "Conflicting providers (i.e., multiple SRPMs produce the same RPM):")
+		conflictingRPMs := make([]string, 0, len(conflictingProviders))
+		for rpm := range conflictingProviders {
+			conflictingRPMs = append(conflictingRPMs, rpm)
+		}
+		sort.Strings(conflictingRPMs)
+		for _, rpm := range conflictingRPMs {
+			writeSummaryLine(writers.info, "--> %s (produced by %s)", rpm, strings.Join(conflictingProviders[rpm], ", "))
+		}
+	}
+
+	unusedBuilds := unusedBuiltNodes(pkgGraph, categories.Built, options.Deliverables)
+	if len(unusedBuilds) != 0 {
+		writeSummaryLine(writers.info, "Potentially unused built packages (i.e., no other SRPM depends on their RPMs):")
+		for _, node := range unusedBuilds {
+			writeSummaryLine(writers.info, "--> %s", writers.srpmName(node))
+		}
+	}
+
+	nodeOutliers := sliceutils.FindMatches(srpmsWithManyNodes(pkgGraph, options.MaxNodesPerSRPM), func(outlier srpmNodeCount) bool {
+		return filter.includesSRPM(outlier.srpmPath)
+	})
+	if len(nodeOutliers) != 0 {
+		writeSummaryLine(writers.info, "SRPMs with more than %d graph nodes (i.e., the spec may generate too many subpackages):", options.MaxNodesPerSRPM)
+		for _, outlier := range nodeOutliers {
+			srpm := outlier.srpmPath
+			if !writers.fullSRPMPaths {
+				srpm = filepath.Base(srpm)
+			}
+			writeSummaryLine(writers.info, "--> %s (%d nodes)", srpm, outlier.count)
+		}
+	}
+
+	orphans := sliceutils.FindMatches(orphanedRunNodes(pkgGraph), filter.includes)
+	if len(orphans) != 0 {
+		writeSummaryLine(writers.info, "Orphaned run nodes (i.e., no build node produces them, the graph may be malformed):")
+		for _, node := range orphans {
+			writeSummaryLine(writers.info, "--> %s", node.FriendlyName())
+		}
+	}
+
+	writers = allWriters
+
+	if len(rpmConflicts) != 0 {
+		writeSummaryLine(writers.conflicts, "RPM conflicts with toolchain: ")
+		for _, conflict := range rpmConflicts {
+			writeSummaryLine(writers.conflicts, "--> %s%s", conflict, conflictAttribution(conflict, buildState))
+		}
+	}
+
+	if len(srpmConflicts) != 0 {
+		writeSummaryLine(writers.conflicts, "SRPM conflicts with toolchain: ")
+		for _, conflict := range srpmConflicts {
+			writeSummaryLine(writers.conflicts, "--> %s", conflict)
+		}
+	}
+}
+
+// slowestBuiltNodes returns up to maxNodes built nodes with the longest build durations, slowest first.
+// Nodes without a recorded build duration are ignored.
+func slowestBuiltNodes(builtNodes map[string]*pkggraph.PkgNode, buildState *GraphBuildState, maxNodes int) (slowest []*pkggraph.PkgNode) {
+	for _, node := range builtNodes {
+		if buildState.NodeBuildDuration(node) > 0 {
+			slowest = append(slowest, node)
+		}
+	}
+
+	sort.Slice(slowest, func(i, j int) bool {
+		return buildState.NodeBuildDuration(slowest[i]) > buildState.NodeBuildDuration(slowest[j])
+	})
+
+	if len(slowest) > maxNodes {
+		slowest = slowest[:maxNodes]
+	}
+
+	return
+}
+
+// overBudgetContributors returns up to maxNodes build nodes of the critical path with the longest build durations first.
+// The critical path bounds the wall clock time of the build, so these are the builds which contributed most to exceeding
+// a time budget. Only the build nodes included by filter are returned.
+// The caller is responsible for holding the graph's read lock.
+func overBudgetContributors(pkgGraph *pkggraph.PkgGraph, buildState *GraphBuildState, filter summaryFilter, maxNodes int) (contributors []*pkggraph.PkgNode) {
+	criticalPath, _ := CalculateCriticalPath(pkgGraph, buildState)
+	contributors = sliceutils.FindMatches(criticalPath, filter.includes)
+
+	sort.SliceStable(contributors, func(i, j int) bool {
+		return buildState.NodeBuildDuration(contributors[i]) > buildState.NodeBuildDuration(contributors[j])
+	})
+
+	if len(contributors) > maxNodes {
+		contributors = contributors[:maxNodes]
+	}
+
+	return
+}
+
+// memoryHungryBuilds returns up to maxResults results of built and failed SRPMs with the highest peak memory first.
+// Results with an unknown peak memory are sorted last, nothing is returned if the peak memory of every build is unknown.
+func memoryHungryBuilds(categories *BuildNodeCategories, buildState *GraphBuildState, maxResults int) (results []*BuildResult) {
+	anyKnown := false
+	addResult := func(res *BuildResult) {
+		if res == nil || res.Attempts == 0 {
+			return
+		}
+		anyKnown = anyKnown || res.PeakRSS > 0
+		results = append(results, res)
+	}
+
+	for _, node := range categories.Built {
+		addResult(buildState.NodeBuildResult(node))
+	}
+	for _, failure := range categories.Failures {
+		addResult(failure)
+	}
+
+	if !anyKnown {
+		return nil
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].PeakRSS != results[j].PeakRSS {
+			return results[i].PeakRSS > results[j].PeakRSS
+		}
+		return results[i].Node.SRPMFileName() < results[j].Node.SRPMFileName()
+	})
+
+	if len(results) > maxResults {
+		results = results[:maxResults]
+	}
+
+	return
+}
+
+// formatPeakRSS formats a peak resident memory size, zero is reported as "unknown" since no build uses no memory.
+func formatPeakRSS(peakRSS int64) string {
+	if peakRSS <= 0 {
+		return "unknown"
+	}
+	return formatByteSize(peakRSS)
+}
+
+// cumulativeBuildDuration returns the sum of the time spent building all built and failed SRPMs.
+func cumulativeBuildDuration(categories *BuildNodeCategories, buildState *GraphBuildState) (cumulative time.Duration) {
+	for _, node := range categories.Built {
+		cumulative += buildState.NodeBuildDuration(node)
+	}
+	for _, node := range categories.Failed {
+		cumulative += buildState.NodeBuildDuration(node)
+	}
+
+	return
+}
+
+// averageAllocatedCores returns the average number of cores allocated to the built and failed SRPMs.
+// builds is the number of builds the average was calculated over, those without a known core count are ignored.
+func averageAllocatedCores(categories *BuildNodeCategories, buildState *GraphBuildState) (average float64, builds int) {
+	totalCores := 0
+	addResult := func(res *BuildResult) {
+		if res != nil && res.Cores > 0 {
+			totalCores += res.Cores
+			builds++
+		}
+	}
+
+	for _, node := range categories.Built {
+		addResult(buildState.NodeBuildResult(node))
+	}
+	for _, failure := range categories.Failures {
+		addResult(failure)
+	}
+
+	if builds != 0 {
+		average = float64(totalCores) / float64(builds)
+	}
+
+	return
+}
+
+// formatPercentage formats count as a whole percentage of total, e.g. "80%". Returns "n/a" if total is zero.
+func formatPercentage(count, total int) string {
+	if total == 0 {
+		return "n/a"
+	}
+
+	return fmt.Sprintf("%.0f%%", float64(count)*100/float64(total))
+}
+
+// formatBuildDuration formats a build duration rounded to the second. Zero durations are returned as an empty string.
+func formatBuildDuration(duration time.Duration) string {
+	if duration == 0 {
+		return ""
+	}
+	return duration.Round(time.Second).String()
+}
+
+// sortedSRPMNames returns the alphabetically sorted base names of the SRPMs of the provided nodes.
+func sortedSRPMNames(nodes map[string]*pkggraph.PkgNode) (srpmNames []string) {
+	srpmNames = make([]string, 0, len(nodes))
+	for _, node := range sortedNodes(nodes) {
+		srpmNames = append(srpmNames, node.SRPMFileName())
+	}
+
+	return
+}
+
+// sortedNodes returns the provided nodes sorted alphabetically by their SRPM's base name.
+func sortedNodes(nodes map[string]*pkggraph.PkgNode) (sorted []*pkggraph.PkgNode) {
+	sorted = make([]*pkggraph.PkgNode, 0, len(nodes))
+	for _, node := range nodes {
+		sorted = append(sorted, node)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].SRPMFileName() < sorted[j].SRPMFileName()
+	})
+
+	return
+}
+
+// sortedFailures returns a copy of the build failures sorted alphabetically by SRPM name.
+func sortedFailures(failures []*BuildResult) (sorted []*BuildResult) {
+	sorted = make([]*BuildResult, len(failures))
+	copy(sorted, failures)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Node.SRPMFileName() < sorted[j].Node.SRPMFileName()
+	})
+
+	return
+}
+
+// groupByCacheMissReason groups built nodes by the reason they could not use the cache.
+// Nodes without a recorded cache miss reason are ignored. Each group is sorted by SRPM name.
+func groupByCacheMissReason(builtNodes map[string]*pkggraph.PkgNode, buildState *GraphBuildState) (groups map[CacheMissReason][]*pkggraph.PkgNode) {
+	groups = make(map[CacheMissReason][]*pkggraph.PkgNode)
+	for _, node := range sortedNodes(builtNodes) {
+		reason := buildState.NodeCacheMissReason(node)
+		if reason != CacheMissNone {
+			groups[reason] = append(groups[reason], node)
+		}
+	}
+
+	return
+}
+
+// architectureCounts holds the number of SRPMs in each build category for a single architecture.
+type architectureCounts struct {
+	built         int
+	prebuilt      int
+	prebuiltDelta int
+	skipped       int
+	failed        int
+	blocked       int
+}
+
+// countByArchitecture counts the SRPMs in each build category, grouped by their target architecture.
+func countByArchitecture(categories *BuildNodeCategories) (archCounts map[string]*architectureCounts) {
+	archCounts = make(map[string]*architectureCounts)
+
+	countsFor := func(node *pkggraph.PkgNode) *architectureCounts {
+		counts, found := archCounts[node.Architecture]
+		if !found {
+			counts = &architectureCounts{}
+			archCounts[node.Architecture] = counts
+		}
+		return counts
+	}
+
+	for _, node := range categories.Built {
+		countsFor(node).built++
+	}
+	for _, node := range categories.Prebuilt {
+		countsFor(node).prebuilt++
+	}
+	for _, node := range categories.PrebuiltDelta {
+		countsFor(node).prebuiltDelta++
+	}
+	for _, node := range categories.Skipped {
+		countsFor(node).skipped++
+	}
+	for _, node := range categories.Failed {
+		countsFor(node).failed++
+	}
+	for _, node := range categories.Unbuilt {
+		countsFor(node).blocked++
+	}
+
+	return
+}
+
+// retriedBuiltNodes returns the built nodes which needed more than one attempt to build, sorted by SRPM name.
+func retriedBuiltNodes(builtNodes map[string]*pkggraph.PkgNode, buildState *GraphBuildState) (retriedNodes []*pkggraph.PkgNode) {
+	for _, node := range sortedNodes(builtNodes) {
+		res := buildState.NodeBuildResult(node)
+		if res != nil && res.Attempts > 1 {
+			retriedNodes = append(retriedNodes, node)
+		}
+	}
+
+	return
+}
+
+// builtWithoutRPMs returns the built nodes whose build succeeded without producing any RPMs, sorted by SRPM name.
+func builtWithoutRPMs(builtNodes map[string]*pkggraph.PkgNode, buildState *GraphBuildState) (emptyNodes []*pkggraph.PkgNode) {
+	for _, node := range sortedNodes(builtNodes) {
+		res := buildState.NodeBuildResult(node)
+		if res != nil && len(res.BuiltFiles) == 0 {
+			emptyNodes = append(emptyNodes, node)
+		}
+	}
+
+	return
+}
+
+// builtRPMCount returns the number of RPMs produced by the built nodes.
+func builtRPMCount(builtNodes map[string]*pkggraph.PkgNode, buildState *GraphBuildState) (count int) {
+	for _, node := range builtNodes {
+		if res := buildState.NodeBuildResult(node); res != nil {
+			count += len(res.BuiltFiles)
+		}
+	}
+
+	return
+}
+
+// deltaSkippedNodes returns the built nodes which were delta nodes, i.e. delta mode was on but their delta RPMs were
+// not used and the SRPM was built instead. Sorted by SRPM name.
+func deltaSkippedNodes(builtNodes map[string]*pkggraph.PkgNode, buildState *GraphBuildState) (skippedNodes []*pkggraph.PkgNode) {
+	for _, node := range sortedNodes(builtNodes) {
+		if buildState.IsNodeDelta(node) {
+			skippedNodes = append(skippedNodes, node)
+		}
+	}
+
+	return
+}
+
+// forcedRebuilds returns the built nodes which were rebuilt even though all of their cached RPMs were available,
+// sorted by SRPM name.
+func forcedRebuilds(builtNodes map[string]*pkggraph.PkgNode, buildState *GraphBuildState) (forcedNodes []*pkggraph.PkgNode) {
+	for _, node := range sortedNodes(builtNodes) {
+		if buildState.WasNodeForcedRebuild(node) {
+			forcedNodes = append(forcedNodes, node)
+		}
+	}
+
+	return
+}
+
+// nodesWithFailureType returns the nodes whose build result has the requested failure type, sorted by SRPM name.
+func nodesWithFailureType(nodes map[string]*pkggraph.PkgNode, buildState *GraphBuildState, failureType FailureType) (matchingNodes []*pkggraph.PkgNode) {
+	for _, node := range sortedNodes(nodes) {
+		res := buildState.NodeBuildResult(node)
+		if res != nil && res.FailureType == failureType {
+			matchingNodes = append(matchingNodes, node)
+		}
+	}
+
+	return
+}
+
+// timedOutBuilds returns the failures which were caused by the build exceeding its timeout, sorted by SRPM name.
+func timedOutBuilds(failures []*BuildResult) (timedOut []*BuildResult) {
+	for _, failure := range sortedFailures(failures) {
+		if failure.TimedOut {
+			timedOut = append(timedOut, failure)
+		}
+	}
+
+	return
+}
+
+// conflictAttribution describes which SRPM rebuilt a conflicting toolchain RPM and why it could not use the cache,
+// including the rebuilt dependency which pulled it in if there was one.
+// Returns an empty string if the source of the conflict is unknown.
+func conflictAttribution(rpm string, buildState *GraphBuildState) string {
+	source := buildState.ConflictingRPMSource(rpm)
+	if source == nil {
+		return ""
+	}
+
+	res := buildState.NodeBuildResult(source)
+	switch {
+	case res == nil || res.CacheMissReason == CacheMissNone:
+		return fmt.Sprintf(" (rebuilt by %s)", source.SRPMFileName())
+	case res.RebuiltDep != nil:
+		return fmt.Sprintf(" (rebuilt by %s, reason: %s: %s)", source.SRPMFileName(), res.CacheMissReason, res.RebuiltDep.SRPMFileName())
+	default:
+		return fmt.Sprintf(" (rebuilt by %s, reason: %s)", source.SRPMFileName(), res.CacheMissReason)
+	}
+}
+
+// sortedArchitectures returns the architectures found in archCounts in alphabetical order.
+func sortedArchitectures(archCounts map[string]*architectureCounts) (archs []string) {
+	for arch := range archCounts {
+		archs = append(archs, arch)
+	}
+	sort.Strings(archs)
+
+	return
+}
+
+// externalRuntimeDependencies returns the sorted, unique RPMs of the remote run nodes which were resolved to a package
+// from a repo, i.e. the packages the build consumed instead of building them. RPMs are listed by their file name along
+// with the repo they came from, if known. Nodes without an RPM file are listed by their package name.
+// Only the packages an SRPM included by filter depends on are returned.
+func externalRuntimeDependencies(pkgGraph *pkggraph.PkgGraph, filter summaryFilter) (dependencies []string) {
+	dependencySet := make(map[string]bool)
+	for _, node := range pkgGraph.AllRunNodes() {
+		if node.Type != pkggraph.TypeRemoteRun || node.State != pkggraph.StateCached || !filter.includesDependentOf(pkgGraph, node) {
+			continue
+		}
+
+		dependency := node.VersionedPkg.String()
+		if node.RpmPath != "" && node.RpmPath != "<NO_RPM_PATH>" {
+			dependency = filepath.Base(node.RpmPath)
+		}
+		if isRemoteSourceRepo(node.SourceRepo) {
+			dependency = fmt.Sprintf("%s (from: %s)", dependency, node.SourceRepo)
+		}
+		dependencySet[dependency] = true
+	}
+
+	dependencies = sliceutils.SetToSlice(dependencySet)
+	sort.Strings(dependencies)
+
+	return
+}
+
+// unresolvedDependencyConsumers maps each unresolved dependency to the sorted names of the local SRPMs which depend on it,
+// as given by srpmName.
+func unresolvedDependencyConsumers(pkgGraph *pkggraph.PkgGraph, srpmName func(node *pkggraph.PkgNode) string) (consumers map[string][]string) {
+	consumerSets := make(map[string]map[string]bool)
+
+	for _, node := range pkgGraph.AllRunNodes() {
+		if node.State != pkggraph.StateUnresolved {
+			continue
+		}
+
+		dependency := node.VersionedPkg.String()
+		if consumerSets[dependency] == nil {
+			consumerSets[dependency] = make(map[string]bool)
+		}
+
+		dependents := pkgGraph.To(node.ID())
+		for dependents.Next() {
+			dependent := dependents.Node().(*pkggraph.PkgNode)
+			if dependent.Type == pkggraph.TypeLocalBuild || dependent.Type == pkggraph.TypeLocalRun {
+				consumerSets[dependency][srpmName(dependent)] = true
+			}
+		}
+	}
+
+	consumers = make(map[string][]string)
+	for dependency, srpms := range consumerSets {
+		consumers[dependency] = sliceutils.SetToSlice(srpms)
+		sort.Strings(consumers[dependency])
+	}
+
+	return
+}
+
+// orphanedRunNodes returns the local run nodes which don't depend on a build node, sorted by name.
+// Every local package is produced by building its SRPM, so such a node points at a bug in the graph construction.
+// Remote run nodes are expected to have no build node and are not reported.
+func orphanedRunNodes(pkgGraph *pkggraph.PkgGraph) (orphans []*pkggraph.PkgNode) {
+	for _, node := range pkgGraph.AllRunNodes() {
+		if node.Type != pkggraph.TypeLocalRun {
+			continue
+		}
+
+		hasBuildNode := false
+		dependencies := pkgGraph.From(node.ID())
+		for dependencies.Next() {
+			if dependencies.Node().(*pkggraph.PkgNode).Type == pkggraph.TypeLocalBuild {
+				hasBuildNode = true
+				break
+			}
+		}
+
+		if !hasBuildNode {
+			orphans = append(orphans, node)
+		}
+	}
+
+	sort.Slice(orphans, func(i, j int) bool {
+		return orphans[i].FriendlyName() < orphans[j].FriendlyName()
+	})
+
+	return
+}
+
+// unusedBuiltNodes returns the built nodes whose RPMs no other SRPM depends on, sorted by SRPM name. Only goal nodes
+// and the nodes of the same SRPM depend on them, so they may be pruned from the package set, unless they are standalone
+// deliverables: SRPMs whose base name matches one of the deliverables glob patterns are not reported.
+// The caller is responsible for holding the graph's read lock.
+func unusedBuiltNodes(pkgGraph *pkggraph.PkgGraph, builtNodes map[string]*pkggraph.PkgNode, deliverables []string) (unused []*pkggraph.PkgNode) {
+	isConsumed := func(buildNode *pkggraph.PkgNode) bool {
+		visited := map[*pkggraph.PkgNode]bool{buildNode: true}
+		queue := []*pkggraph.PkgNode{buildNode}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+
+			dependents := pkgGraph.To(current.ID())
+			for dependents.Next() {
+				dependent := dependents.Node().(*pkggraph.PkgNode)
+				switch {
+				case visited[dependent] || dependent.Type == pkggraph.TypeGoal:
+					continue
+				case dependent.Type != pkggraph.TypePureMeta && dependent.SrpmPath != buildNode.SrpmPath:
+					return true
+				}
+
+				// Walk through the SRPM's own run nodes and meta nodes to the packages depending on them.
+				visited[dependent] = true
+				queue = append(queue, dependent)
+			}
+		}
+
+		return false
+	}
+
+	for _, node := range sortedNodes(builtNodes) {
+		if !matchesPackageFilter(node, deliverables) && !isConsumed(node) {
+			unused = append(unused, node)
+		}
+	}
+
+	return
+}
+
+// conflictingRPMProviders returns the RPMs produced by more than one SRPM, mapped to the sorted names of those SRPMs
+// as given by srpmName. An RPM is produced by a build node if it is the node's RPM path or one of the files recorded in
+// its build result. RPMs are compared by their base name. Only the conflicts with at least one provider accepted by
+// include are returned, listing all of their providers.
+func conflictingRPMProviders(pkgGraph *pkggraph.PkgGraph, buildState *GraphBuildState, srpmName func(node *pkggraph.PkgNode) string, include func(node *pkggraph.PkgNode) bool) (conflicts map[string][]string) {
+	providers := make(map[string]map[string]bool)
+	included := make(map[string]bool)
+	addProvider := func(rpmPath string, node *pkggraph.PkgNode) {
+		rpm := filepath.Base(rpmPath)
+		if providers[rpm] == nil {
+			providers[rpm] = make(map[string]bool)
+		}
+		providers[rpm][srpmName(node)] = true
+		included[rpm] = included[rpm] || include(node)
+	}
+
+	for _, node := range pkgGraph.AllBuildNodes() {
+		if node.RpmPath != "" && node.RpmPath != "<NO_RPM_PATH>" {
+			addProvider(node.RpmPath, node)
+		}
+		if res := buildState.NodeBuildResult(node); res != nil {
+			for _, builtFile := range res.BuiltFiles {
+				addProvider(builtFile, node)
+			}
+		}
+	}
+
+	conflicts = make(map[string][]string)
+	for rpm, srpms := range providers {
+		if len(srpms) > 1 && included[rpm] {
+			conflicts[rpm] = sliceutils.SetToSlice(srpms)
+			sort.Strings(conflicts[rpm])
+		}
+	}
+
+	return
 }
@@ -0,0 +1,946 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/logger"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkgjson"
+	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	logger.InitStderrLog()
+	os.Exit(m.Run())
+}
+
+// addTestPackage adds a run node and its build node for a package built from "<name>-1.0-1.cm2.src.rpm", laid out as
+// the graph of a toolkit build: SRPMs and RPMs in the output directory, specs and their sources in the SPECS directory.
+func addTestPackage(t *testing.T, g *pkggraph.PkgGraph, name string) (runNode, buildNode *pkggraph.PkgNode) {
+	var (
+		pkgVer    = &pkgjson.PackageVer{Name: name, Version: "1.0-1.cm2"}
+		srpmPath  = fmt.Sprintf("/mariner/out/SRPMS/%s-1.0-1.cm2.src.rpm", name)
+		rpmPath   = fmt.Sprintf("/mariner/out/RPMS/x86_64/%s-1.0-1.cm2.x86_64.rpm", name)
+		specPath  = fmt.Sprintf("/mariner/SPECS/%s/%s.spec", name, name)
+		sourceDir = filepath.Dir(specPath)
+	)
+
+	runNode, err := g.AddPkgNode(pkgVer, pkggraph.StateMeta, pkggraph.TypeLocalRun, srpmPath, rpmPath, specPath, sourceDir, "x86_64", "<LOCAL>")
+	assert.NoError(t, err)
+
+	buildNode, err = g.AddPkgNode(pkgVer, pkggraph.StateBuild, pkggraph.TypeLocalBuild, srpmPath, rpmPath, specPath, sourceDir, "x86_64", "<LOCAL>")
+	assert.NoError(t, err)
+
+	assert.NoError(t, g.AddEdge(runNode, buildNode))
+
+	return
+}
+
+// recordTestResult records a build result for a build node. Nodes which did not use the cache are sent to the build agent once.
+func recordTestResult(buildState *GraphBuildState, buildNode *pkggraph.PkgNode, usedCache, wasDelta bool, err error) {
+	attempts := 0
+	if !usedCache {
+		attempts = 1
+	}
+
+	var builtFiles []string
+	if err == nil {
+		builtFiles = []string{buildNode.RpmPath}
+	}
+
+	res := &BuildResult{
+		Node:           buildNode,
+		AncillaryNodes: []*pkggraph.PkgNode{buildNode},
+		Attempts:       attempts,
+		BuiltFiles:     builtFiles,
+		UsedCache:      usedCache,
+		WasDelta:       wasDelta,
+		Err:            err,
+	}
+	if usedCache {
+		res.CacheSource = cacheSource(&BuildRequest{AncillaryNodes: res.AncillaryNodes}, builtFiles)
+	}
+
+	buildState.RecordBuildResult(res)
+}
+
+// buildTestSummaryGraph creates a graph with one SRPM per build category:
+// - "built" was built, "cached" and "delta" were restored from the cache.
+// - "failed" failed to build, "blocked" depends on it and "blocked2" depends on "blocked".
+// - "skipped" was skipped per user request, "available" was available without being built.
+// - "missing" is an unresolved remote dependency.
+func buildTestSummaryGraph(t *testing.T) (g *pkggraph.PkgGraph, buildState *GraphBuildState, buildNodes map[string]*pkggraph.PkgNode) {
+	g = pkggraph.NewPkgGraph()
+	buildState = NewGraphBuildState(nil)
+	buildNodes = make(map[string]*pkggraph.PkgNode)
+
+	runNodes := make(map[string]*pkggraph.PkgNode)
+	for _, name := range []string{"built", "cached", "delta", "skipped", "available", "failed", "blocked", "blocked2"} {
+		runNodes[name], buildNodes[name] = addTestPackage(t, g, name)
+	}
+
+	missingNode, err := g.AddPkgNode(&pkgjson.PackageVer{Name: "missing"}, pkggraph.StateUnresolved, pkggraph.TypeRemoteRun, "<NO_SRPM_PATH>", "<NO_RPM_PATH>", "<NO_SPEC_PATH>", "<NO_SOURCE_PATH>", "<NO_ARCHITECTURE>", "<NO_REPO>")
+	assert.NoError(t, err)
+
+	assert.NoError(t, g.AddEdge(buildNodes["blocked"], runNodes["failed"]))
+	assert.NoError(t, g.AddEdge(buildNodes["blocked2"], runNodes["blocked"]))
+	assert.NoError(t, g.AddEdge(buildNodes["blocked2"], missingNode))
+
+	// The delta fetcher points delta nodes at the RPM it downloaded and records the repo it came from.
+	for _, node := range []*pkggraph.PkgNode{runNodes["delta"], buildNodes["delta"]} {
+		node.State = pkggraph.StateDelta
+		node.RpmPath = "/mariner/build/rpm_cache/cache/delta-1.0-1.cm2.x86_64.rpm"
+		node.SourceRepo = "mariner-official-base"
+	}
+
+	recordTestResult(buildState, buildNodes["built"], false, false, nil)
+	recordTestResult(buildState, buildNodes["cached"], true, false, nil)
+	recordTestResult(buildState, buildNodes["delta"], true, true, nil)
+	buildState.RecordBuildResult(&BuildResult{
+		Node:           buildNodes["skipped"],
+		AncillaryNodes: []*pkggraph.PkgNode{buildNodes["skipped"]},
+		Skipped:        true,
+	})
+	buildState.RecordBuildResult(&BuildResult{
+		Node:           buildNodes["available"],
+		AncillaryNodes: []*pkggraph.PkgNode{buildNodes["available"]},
+	})
+	recordTestResult(buildState, buildNodes["failed"], false, false, fmt.Errorf("build failed"))
+
+	return
+}
+
+func TestCategorizeBuildNodesPlacesEachNodeInExactlyOneCategory(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+
+	categories := CategorizeBuildNodes(g, buildState)
+
+	expectedCategories := map[string]map[string]*pkggraph.PkgNode{
+		"built":     categories.Built,
+		"cached":    categories.Prebuilt,
+		"delta":     categories.PrebuiltDelta,
+		"skipped":   categories.Skipped,
+		"available": categories.AlreadyAvailable,
+		"failed":    categories.Failed,
+		"blocked":   categories.Unbuilt,
+		"blocked2":  categories.Unbuilt,
+	}
+
+	allCategories := []map[string]*pkggraph.PkgNode{
+		categories.Built,
+		categories.AlreadyAvailable,
+		categories.Prebuilt,
+		categories.PrebuiltDelta,
+		categories.Skipped,
+		categories.Failed,
+		categories.Unbuilt,
+	}
+
+	for name, node := range buildNodes {
+		foundCount := 0
+		for _, category := range allCategories {
+			if _, found := category[node.SrpmPath]; found {
+				foundCount++
+			}
+		}
+		assert.Equal(t, 1, foundCount, "'%s' must be in exactly one category", name)
+		assert.Contains(t, expectedCategories[name], node.SrpmPath, "'%s' is in the wrong category", name)
+	}
+
+	assert.Len(t, categories.Failures, 1)
+	assert.Len(t, categories.Unresolved, 1)
+	assert.True(t, categories.Unresolved[(&pkgjson.PackageVer{Name: "missing"}).String()])
+}
+
+func TestCategorizeBuildNodesEmptyGraph(t *testing.T) {
+	categories := CategorizeBuildNodes(pkggraph.NewPkgGraph(), NewGraphBuildState(nil))
+
+	assert.Empty(t, categories.Built)
+	assert.Empty(t, categories.AlreadyAvailable)
+	assert.Empty(t, categories.Prebuilt)
+	assert.Empty(t, categories.PrebuiltDelta)
+	assert.Empty(t, categories.Skipped)
+	assert.Empty(t, categories.Failed)
+	assert.Empty(t, categories.Unbuilt)
+	assert.Empty(t, categories.Unresolved)
+	assert.Empty(t, categories.Failures)
+}
+
+func TestCountByArchitectureSplitsCategoriesPerArch(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+	buildNodes["delta"].Architecture = "aarch64"
+	buildNodes["blocked2"].Architecture = "aarch64"
+
+	archCounts := countByArchitecture(CategorizeBuildNodes(g, buildState))
+
+	assert.Equal(t, []string{"aarch64", "x86_64"}, sortedArchitectures(archCounts))
+	assert.Equal(t, architectureCounts{prebuiltDelta: 1, blocked: 1}, *archCounts["aarch64"])
+	assert.Equal(t, architectureCounts{built: 1, prebuilt: 1, skipped: 1, failed: 1, blocked: 1}, *archCounts["x86_64"])
+}
+
+func TestWriteCSVAtomicallyReplacesPreviousFile(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "summary.csv")
+	assert.NoError(t, os.WriteFile(outputPath, []byte("old contents\n"), 0644))
+
+	err := writeCSVAtomically([][]string{{"Package", "State"}, {"a.src.rpm", "Built"}}, nil, outputPath)
+	assert.NoError(t, err)
+
+	contents, err := os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "Package,State\na.src.rpm,Built\n", string(contents))
+
+	leftovers, err := filepath.Glob(outputPath + ".tmp-*")
+	assert.NoError(t, err)
+	assert.Empty(t, leftovers)
+}
+
+func TestRecordBuildSummaryCreatesMissingOutputDirectory(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+	outputPath := filepath.Join(t.TempDir(), "missing", "dir", "summary.csv")
+
+	RecordBuildSummary(g, &sync.RWMutex{}, buildState, outputPath, SummaryCSVOptions{Columns: []string{"Package", "State"}})
+
+	contents, err := os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(contents), "\nbuilt-1.0-1.cm2.src.rpm,Built\n")
+}
+
+func TestRecordBuildSummaryLogsContentsIfUnwritable(t *testing.T) {
+	hook := &logrustest.Hook{}
+	previousHooks := logger.Log.ReplaceHooks(make(logrus.LevelHooks))
+	t.Cleanup(func() { logger.Log.ReplaceHooks(previousHooks) })
+	logger.Log.AddHook(hook)
+
+	// A regular file in place of the parent directory can't be replaced by a directory.
+	blockingFile := filepath.Join(t.TempDir(), "not-a-dir")
+	assert.NoError(t, os.WriteFile(blockingFile, nil, 0644))
+
+	g, buildState, _ := buildTestSummaryGraph(t)
+	RecordBuildSummary(g, &sync.RWMutex{}, buildState, filepath.Join(blockingFile, "summary.csv"), SummaryCSVOptions{Columns: []string{"Package", "State"}})
+
+	entries := hook.AllEntries()
+	if assert.NotEmpty(t, entries) {
+		assert.Contains(t, hook.LastEntry().Message, "Package,State\n")
+		assert.Contains(t, hook.LastEntry().Message, "\nbuilt-1.0-1.cm2.src.rpm,Built\n")
+	}
+}
+
+func TestUnresolvedDependencyConsumersListsDependentSRPMs(t *testing.T) {
+	g, _, _ := buildTestSummaryGraph(t)
+
+	consumers := unresolvedDependencyConsumers(g, (*pkggraph.PkgNode).SRPMFileName)
+
+	assert.Equal(t, map[string][]string{
+		(&pkgjson.PackageVer{Name: "missing"}).String(): {"blocked2-1.0-1.cm2.src.rpm"},
+	}, consumers)
+}
+
+func TestPrintBuildResultQuietOnlyLogsFailuresAndWarnings(t *testing.T) {
+	hook := &logrustest.Hook{}
+	previousHooks := logger.Log.ReplaceHooks(make(logrus.LevelHooks))
+	t.Cleanup(func() { logger.Log.ReplaceHooks(previousHooks) })
+	logger.Log.AddHook(hook)
+
+	g := pkggraph.NewPkgGraph()
+	_, builtNode := addTestPackage(t, g, "built")
+	_, cachedNode := addTestPackage(t, g, "cached")
+	_, staleNode := addTestPackage(t, g, "stale")
+	_, failedNode := addTestPackage(t, g, "failed")
+
+	PrintBuildResult(&BuildResult{Node: builtNode, BuiltFiles: []string{builtNode.RpmPath}}, true)
+	PrintBuildResult(&BuildResult{Node: cachedNode, UsedCache: true}, true)
+	assert.Empty(t, hook.AllEntries())
+
+	PrintBuildResult(&BuildResult{Node: staleNode, UsedCache: true, CacheMayBeStale: true}, true)
+	PrintBuildResult(&BuildResult{Node: failedNode, Err: fmt.Errorf("build failed")}, true)
+
+	entries := hook.AllEntries()
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, logrus.WarnLevel, entries[0].Level)
+		assert.Equal(t, logrus.ErrorLevel, entries[1].Level)
+	}
+
+	hook.Reset()
+	PrintBuildResult(&BuildResult{Node: builtNode, BuiltFiles: []string{builtNode.RpmPath}}, false)
+	assert.Len(t, hook.AllEntries(), 1)
+}
+
+func TestPrintBuildSummaryToWritesCountsAndListings(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+
+	summary := output.String()
+	assert.Contains(t, summary, "Number of built SRPMs:             1 (12%)\n")
+	assert.Contains(t, summary, "Number of failed SRPMs:            1 (12%)\n")
+	assert.Contains(t, summary, "Number of blocked SRPMs:           2 (25%)\n")
+	assert.Contains(t, summary, "Failed SRPMs:\n--> failed-1.0-1.cm2.src.rpm , error: build failed")
+	assert.NotContains(t, summary, "more\n")
+}
+
+func TestPrintBuildSummaryToListsDeltaRepoPackages(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+
+	buildNodes["delta"].RpmPath = "/cache/delta-1.0-1.cm2.x86_64.rpm"
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+	assert.Contains(t, output.String(), "used instead of building):\n--> delta-1.0-1.cm2.src.rpm (repo package: delta-1.0-1.cm2.x86_64.rpm, from: mariner-official-base)\n")
+}
+
+func TestPrintBuildSummaryToLimitsListedFailures(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+	recordTestResult(buildState, buildNodes["blocked"], false, false, fmt.Errorf("build failed"))
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{MaxFailuresListed: 1})
+
+	summary := output.String()
+	assert.Contains(t, summary, "Number of failed SRPMs:            2 (25%)\n")
+	assert.Contains(t, summary, "--> blocked-1.0-1.cm2.src.rpm , error: build failed")
+	assert.NotContains(t, summary, "--> failed-1.0-1.cm2.src.rpm , error: build failed")
+	assert.Contains(t, summary, "... and 1 more\n")
+}
+
+func TestPrintBuildSummaryToListsTimedOutBuilds(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+	buildState.NodeBuildResult(buildNodes["failed"]).TimedOut = true
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+
+	summary := output.String()
+	assert.Contains(t, summary, "Number of failed SRPMs:            1 (12%)\n")
+	assert.Contains(t, summary, "Number of timed-out SRPMs:         1\n")
+	assert.Contains(t, summary, "Timed-out SRPMs (i.e., the build was killed after exceeding the timeout):\n--> failed-1.0-1.cm2.src.rpm")
+}
+
+func TestPrintBuildSummaryToTalliesTestFailuresSeparately(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+	buildState.NodeBuildResult(buildNodes["built"]).FailureType = FailureTest
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+
+	summary := output.String()
+	assert.Contains(t, summary, "Number of built SRPMs:             1 (12%)\n")
+	assert.Contains(t, summary, "Number of failed SRPMs:            1 (12%)\n")
+	assert.Contains(t, summary, "Number of SRPMs with failed tests:  1\n")
+	assert.Contains(t, summary, "check section failed):\n--> built-1.0-1.cm2.src.rpm")
+}
+
+func TestRecordBuildSummaryIncludesAttempts(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+	buildState.NodeBuildResult(buildNodes["built"]).Attempts = 3
+
+	outputPath := filepath.Join(t.TempDir(), "summary.csv")
+	columns := []string{"Package", "State", "Blocker", "Blocker Chain", "Architecture", "Attempts", "Cache Source", "Version", "Release"}
+	RecordBuildSummary(g, &sync.RWMutex{}, buildState, outputPath, SummaryCSVOptions{Columns: columns})
+
+	contents, err := os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(contents), "Package,State,Blocker,Blocker Chain,Architecture,Attempts,Cache Source,Version,Release\n")
+	assert.Contains(t, string(contents), "built-1.0-1.cm2.src.rpm,Built,,,x86_64,3,,1.0,1.cm2\n")
+	assert.Contains(t, string(contents), "cached-1.0-1.cm2.src.rpm,PreBuilt,,,x86_64,,/mariner/out/RPMS/x86_64,1.0,1.cm2\n")
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+	assert.Contains(t, output.String(), "possibly flaky builds):\n--> built-1.0-1.cm2.src.rpm (3 attempts)\n")
+}
+
+func TestPrintBuildSummaryToReportsBuildTimes(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+	buildState.NodeBuildResult(buildNodes["built"]).Duration = 2 * time.Minute
+	buildState.NodeBuildResult(buildNodes["failed"]).Duration = time.Minute
+
+	assert.Equal(t, 3*time.Minute, cumulativeBuildDuration(CategorizeBuildNodes(g, buildState), buildState))
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{BuildStartTime: time.Now().Add(-time.Minute)})
+	assert.Regexp(t, `Wall clock: 1m0s, Cumulative: 3m0s, Parallel efficiency: 3\.00\n`, output.String())
+}
+
+func TestPrintBuildSummaryToListsPrebuiltCacheDirectory(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+	assert.Contains(t, output.String(), "Prebuilt SRPMs (i.e., restored from the local cache):\n--> cached-1.0-1.cm2.src.rpm (from: /mariner/out/RPMS/x86_64)\n")
+}
+
+func TestPrintBuildSummaryToListsStaleCachedPackages(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+	buildState.RecordBuildResult(&BuildResult{
+		Node:            buildNodes["delta"],
+		AncillaryNodes:  []*pkggraph.PkgNode{buildNodes["delta"]},
+		UsedCache:       true,
+		WasDelta:        true,
+		CacheMayBeStale: true,
+	})
+
+	assert.True(t, buildState.IsNodeCacheStale(buildNodes["delta"]))
+	assert.False(t, buildState.IsNodeCacheStale(buildNodes["cached"]))
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+	assert.Contains(t, output.String(), "than the cached RPMs):\n--> delta-1.0-1.cm2.src.rpm\n")
+}
+
+func TestGetBuildSummaryMatchesCategorizeBuildNodes(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+
+	assert.Equal(t, CategorizeBuildNodes(g, buildState), GetBuildSummary(g, &sync.RWMutex{}, buildState))
+}
+
+func TestUpdateBuildSummaryOverwritesCSVWithoutBlockerChains(t *testing.T) {
+	g, _, buildNodes := buildTestSummaryGraph(t)
+	buildState := NewGraphBuildState(nil)
+	outputPath := filepath.Join(t.TempDir(), "summary.csv")
+
+	UpdateBuildSummary(g, &sync.RWMutex{}, buildState, outputPath)
+	contents, err := os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(contents), "failed-1.0-1.cm2.src.rpm,Unbuilt,\n")
+
+	recordTestResult(buildState, buildNodes["failed"], false, false, fmt.Errorf("build failed"))
+	UpdateBuildSummary(g, &sync.RWMutex{}, buildState, outputPath)
+	contents, err = os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(contents), "failed-1.0-1.cm2.src.rpm,Failed,\n")
+	assert.Contains(t, string(contents), "blocked-1.0-1.cm2.src.rpm,Unbuilt,failed-1.0-1.cm2.src.rpm-FAIL \n")
+}
+
+func TestRecordBuildSummaryCompressesGzipOutput(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+	outputPath := filepath.Join(t.TempDir(), "summary.csv.gz")
+
+	RecordBuildSummary(g, &sync.RWMutex{}, buildState, outputPath, SummaryCSVOptions{})
+
+	csvFile, err := os.Open(outputPath)
+	assert.NoError(t, err)
+	defer csvFile.Close()
+
+	gzipReader, err := gzip.NewReader(csvFile)
+	assert.NoError(t, err)
+	contents, err := io.ReadAll(gzipReader)
+	assert.NoError(t, err)
+	assert.Contains(t, string(contents), "Package,State,Blocker\n")
+	assert.Contains(t, string(contents), "built-1.0-1.cm2.src.rpm,Built,\n")
+}
+
+func TestRecordBuildSummaryWritesMetadataHeader(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+	outputPath := filepath.Join(t.TempDir(), "summary.csv")
+	metadata := &SummaryMetadata{
+		BuildID:        "build-42",
+		Timestamp:      time.Date(2023, time.March, 1, 12, 30, 0, 0, time.UTC),
+		ToolkitVersion: "2.0.1",
+	}
+
+	RecordBuildSummary(g, &sync.RWMutex{}, buildState, outputPath, SummaryCSVOptions{Columns: []string{"Package", "State"}, Metadata: metadata})
+
+	contents, err := os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(contents), "# build-id: build-42\n# timestamp: 2023-03-01T12:30:00Z\n# toolkit-version: 2.0.1\nPackage,State\n"))
+
+	// Readers of the summary skip the header.
+	records, err := readCSVRecords(outputPath)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Package", "State"}, records[0])
+
+	// Appending to a summary with a header keeps its rows.
+	RecordBuildSummary(g, &sync.RWMutex{}, buildState, outputPath, SummaryCSVOptions{Columns: []string{"Package", "State"}, AppendToExisting: true, Metadata: metadata})
+	appendedRecords, err := readCSVRecords(outputPath)
+	assert.NoError(t, err)
+	assert.Equal(t, records, appendedRecords)
+}
+
+func TestRecordBuildSummaryPerState(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+	outputDir := filepath.Join(t.TempDir(), "states")
+
+	RecordBuildSummaryPerState(g, &sync.RWMutex{}, buildState, outputDir, []string{"Package", "State", "Blocker"}, false, nil)
+
+	expectedFiles := map[string]string{
+		"built.csv":             "Package,Blocker\nbuilt-1.0-1.cm2.src.rpm,\n",
+		"already-available.csv": "Package,Blocker\navailable-1.0-1.cm2.src.rpm,\n",
+		"prebuilt.csv":          "Package,Blocker\ncached-1.0-1.cm2.src.rpm,\n",
+		"prebuilt-delta.csv":    "Package,Blocker\ndelta-1.0-1.cm2.src.rpm,\n",
+		"skipped.csv":           "Package,Blocker\nskipped-1.0-1.cm2.src.rpm,\n",
+		"failed.csv":            "Package,Blocker\nfailed-1.0-1.cm2.src.rpm,\n",
+		"blocked.csv":           "Package,Blocker\nblocked-1.0-1.cm2.src.rpm,failed-1.0-1.cm2.src.rpm-FAIL \nblocked2-1.0-1.cm2.src.rpm,blocked-1.0-1.cm2.src.rpm-UNBUILT \n",
+	}
+	for fileName, expectedContents := range expectedFiles {
+		contents, err := os.ReadFile(filepath.Join(outputDir, fileName))
+		assert.NoError(t, err)
+		assert.Equal(t, expectedContents, string(contents), fileName)
+	}
+}
+
+func TestRecordBuildSummaryIncludesVersionAndRelease(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+	buildNodes["built"].VersionedPkg.Version = "1.0-1.cm2"
+
+	version, release := nodeVersionAndRelease(buildNodes["built"])
+	assert.Equal(t, "1.0", version)
+	assert.Equal(t, "1.cm2", release)
+
+	outputPath := filepath.Join(t.TempDir(), "summary.csv")
+	RecordBuildSummary(g, &sync.RWMutex{}, buildState, outputPath, SummaryCSVOptions{Columns: []string{"Package", "State", "Version", "Release"}})
+
+	contents, err := os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(contents), "built-1.0-1.cm2.src.rpm,Built,1.0,1.cm2\n")
+	assert.Contains(t, string(contents), "failed-1.0-1.cm2.src.rpm,Failed,1.0,1.cm2\n")
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+	assert.Contains(t, output.String(), "Built SRPMs:\n--> built-1.0-1.cm2.src.rpm (version: 1.0, release: 1.cm2)\n")
+}
+
+func TestPrintBuildSummaryToListsBuildsWithoutRPMs(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+	assert.Contains(t, output.String(), "Number of built SRPMs without RPMs: 0\n")
+	assert.NotContains(t, output.String(), "Built but produced no RPMs")
+
+	buildState.NodeBuildResult(buildNodes["built"]).BuiltFiles = nil
+
+	output.Reset()
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+	assert.Contains(t, output.String(), "Number of built SRPMs without RPMs: 1\n")
+	assert.Contains(t, output.String(), "Built but produced no RPMs (i.e., the spec may be misconfigured):\n--> built-1.0-1.cm2.src.rpm\n")
+}
+
+func TestBuildSummaryReportsCacheHitRate(t *testing.T) {
+	assert.Equal(t, 0.0, (&BuildNodeCategories{}).CacheHitRate())
+
+	g, buildState, _ := buildTestSummaryGraph(t)
+	categories := GetBuildSummary(g, &sync.RWMutex{}, buildState)
+	assert.InDelta(t, 2.0/3.0, categories.CacheHitRate(), 0.0001)
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+	assert.Contains(t, output.String(), "Cache hit rate: 66.7%\n")
+}
+
+func TestFormatPercentage(t *testing.T) {
+	assert.Equal(t, "80%", formatPercentage(120, 150))
+	assert.Equal(t, "0%", formatPercentage(0, 3))
+	assert.Equal(t, "n/a", formatPercentage(0, 0))
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, pkggraph.NewPkgGraph(), &sync.RWMutex{}, NewGraphBuildState(nil), false, SummaryOptions{})
+	assert.Contains(t, output.String(), "Number of built SRPMs:             0 (n/a)\n")
+}
+
+func TestRecordBuildSummaryWritesSelectedColumns(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+	buildState.NodeBuildResult(buildNodes["built"]).Duration = 90 * time.Second
+
+	outputPath := filepath.Join(t.TempDir(), "summary.csv")
+	RecordBuildSummary(g, &sync.RWMutex{}, buildState, outputPath, SummaryCSVOptions{Columns: []string{"State", "Package", "Duration"}})
+
+	contents, err := os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(contents), "State,Package,Duration\nAlreadyAvailable,available-1.0-1.cm2.src.rpm,"))
+	assert.Contains(t, string(contents), "\nBuilt,built-1.0-1.cm2.src.rpm,"+formatBuildDuration(90*time.Second)+"\n")
+}
+
+func TestPrintBuildCounts(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+
+	var output bytes.Buffer
+	PrintBuildCounts(&output, g, &sync.RWMutex{}, buildState)
+	assert.Equal(t, "built=1 already_available=1 prebuilt=1 prebuilt_delta=1 skipped=1 failed=1 blocked=2 unresolved=1 conflicts=0\n", output.String())
+}
+
+func TestPrintBuildCountsIncludesSRPMConflicts(t *testing.T) {
+	g, _, buildNodes := buildTestSummaryGraph(t)
+	buildState := NewGraphBuildState([]string{"built-1.0-1.cm2.x86_64.rpm"})
+	recordTestResult(buildState, buildNodes["built"], false, false, nil)
+
+	var output bytes.Buffer
+	PrintBuildCounts(&output, g, &sync.RWMutex{}, buildState)
+	assert.Contains(t, output.String(), " conflicts=2\n")
+}
+
+func TestPrintBuildSummaryToAttributesConflicts(t *testing.T) {
+	g, _, buildNodes := buildTestSummaryGraph(t)
+	buildState := NewGraphBuildState([]string{"built-1.0-1.cm2.x86_64.rpm"})
+	buildState.RecordBuildResult(&BuildResult{
+		Node:            buildNodes["built"],
+		AncillaryNodes:  []*pkggraph.PkgNode{buildNodes["built"]},
+		Attempts:        1,
+		BuiltFiles:      []string{"/mariner/out/RPMS/x86_64/built-1.0-1.cm2.x86_64.rpm"},
+		CacheMissReason: CacheMissDependencyRebuilt,
+		RebuiltDep:      buildNodes["failed"],
+	})
+
+	assert.Equal(t, buildNodes["built"], buildState.ConflictingRPMSource("built-1.0-1.cm2.x86_64.rpm"))
+	assert.Nil(t, buildState.ConflictingRPMSource("cached-1.0-1.cm2.x86_64.rpm"))
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+	assert.Contains(t, output.String(), "RPM conflicts with toolchain: \n--> built-1.0-1.cm2.x86_64.rpm (rebuilt by built-1.0-1.cm2.src.rpm, reason: Dependency rebuilt: failed-1.0-1.cm2.src.rpm)\n")
+}
+
+func TestRecordBuildSummaryAppendsToExistingSummary(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+	outputPath := filepath.Join(t.TempDir(), "summary.csv")
+	columns := []string{"Package", "State"}
+
+	// A previous phase built "failed" and a package which is not part of this graph.
+	assert.NoError(t, os.WriteFile(outputPath, []byte("Package,State\nfailed-1.0-1.cm2.src.rpm,Built\nother-1.0-1.cm2.src.rpm,Built\n"), 0644))
+	RecordBuildSummary(g, &sync.RWMutex{}, buildState, outputPath, SummaryCSVOptions{Columns: columns, AppendToExisting: true})
+
+	contents, err := os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(contents), "Package,State\n"))
+	assert.Contains(t, string(contents), "\nfailed-1.0-1.cm2.src.rpm,Failed\n")
+	assert.NotContains(t, string(contents), "failed-1.0-1.cm2.src.rpm,Built\n")
+	assert.Contains(t, string(contents), "\nother-1.0-1.cm2.src.rpm,Built\n")
+
+	// Different columns can't be merged, the file is overwritten.
+	RecordBuildSummary(g, &sync.RWMutex{}, buildState, outputPath, SummaryCSVOptions{Columns: []string{"Package"}, AppendToExisting: true})
+	contents, err = os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(contents), "other-1.0-1.cm2.src.rpm")
+}
+
+func TestFailureLogLocation(t *testing.T) {
+	res := &BuildResult{LogFile: "/logs/pkg.log"}
+	assert.Equal(t, "/logs/pkg.log", failureLogLocation(res))
+
+	res.FailureLine = 42
+	assert.Equal(t, "/logs/pkg.log:42", failureLogLocation(res))
+}
+
+func TestMemoryHungryBuilds(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+	categories := CategorizeBuildNodes(g, buildState)
+
+	// No build reported its peak memory.
+	assert.Empty(t, memoryHungryBuilds(categories, buildState, memoryHungryBuildsToList))
+
+	buildState.NodeBuildResult(buildNodes["failed"]).PeakRSS = 3 << 30
+	results := memoryHungryBuilds(categories, buildState, memoryHungryBuildsToList)
+	assert.Len(t, results, 2)
+	assert.Equal(t, buildNodes["failed"], results[0].Node)
+	assert.Equal(t, buildNodes["built"], results[1].Node)
+
+	assert.Len(t, memoryHungryBuilds(categories, buildState, 1), 1)
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+	assert.Contains(t, output.String(), "Most memory-hungry 2 SRPM builds (by peak RSS):\n--> failed-1.0-1.cm2.src.rpm (3.0 GiB)\n--> built-1.0-1.cm2.src.rpm (unknown)\n")
+}
+
+func TestRecordBuildSummaryRenamesStates(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+	outputPath := filepath.Join(t.TempDir(), "summary.csv")
+
+	stateLabels := map[string]string{"Built": "SUCCESS", "Failed": "FAILURE"}
+	stateLabel := func(state string) string {
+		if label, found := stateLabels[state]; found {
+			return label
+		}
+		return state
+	}
+	RecordBuildSummary(g, &sync.RWMutex{}, buildState, outputPath, SummaryCSVOptions{Columns: []string{"Package", "State"}, StateLabel: stateLabel})
+
+	contents, err := os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(contents), "\nbuilt-1.0-1.cm2.src.rpm,SUCCESS\n")
+	assert.Contains(t, string(contents), "\nfailed-1.0-1.cm2.src.rpm,FAILURE\n")
+	assert.Contains(t, string(contents), "\ncached-1.0-1.cm2.src.rpm,PreBuilt\n")
+}
+
+func TestOrphanedRunNodes(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+	assert.Empty(t, orphanedRunNodes(g))
+
+	orphan, err := g.AddPkgNode(&pkgjson.PackageVer{Name: "orphan", Version: "1.0-1.cm2"}, pkggraph.StateMeta, pkggraph.TypeLocalRun, "/mariner/out/SRPMS/orphan-1.0-1.cm2.src.rpm", "/mariner/out/RPMS/x86_64/orphan-1.0-1.cm2.x86_64.rpm", "/mariner/SPECS/orphan/orphan.spec", "/mariner/SPECS/orphan", "x86_64", "<LOCAL>")
+	assert.NoError(t, err)
+	assert.Equal(t, []*pkggraph.PkgNode{orphan}, orphanedRunNodes(g))
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+	assert.Contains(t, output.String(), "Orphaned run nodes (i.e., no build node produces them, the graph may be malformed):\n--> "+orphan.FriendlyName()+"\n")
+}
+
+func TestConflictingRPMProviders(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+	assert.Empty(t, conflictingRPMProviders(g, buildState, (*pkggraph.PkgNode).SRPMFileName, summaryFilter{}.includes))
+
+	// "other" also packages the RPM produced by "built".
+	_, otherBuild := addTestPackage(t, g, "other")
+	buildState.RecordBuildResult(&BuildResult{
+		Node:           otherBuild,
+		AncillaryNodes: []*pkggraph.PkgNode{otherBuild},
+		Attempts:       1,
+		BuiltFiles:     []string{otherBuild.RpmPath, "/mariner/out/RPMS/x86_64/built-1.0-1.cm2.x86_64.rpm"},
+	})
+
+	conflicts := conflictingRPMProviders(g, buildState, (*pkggraph.PkgNode).SRPMFileName, summaryFilter{}.includes)
+	assert.Equal(t, map[string][]string{
+		filepath.Base(buildNodes["built"].RpmPath): {"built-1.0-1.cm2.src.rpm", "other-1.0-1.cm2.src.rpm"},
+	}, conflicts)
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+	assert.Contains(t, output.String(), "Conflicting providers (i.e., multiple SRPMs produce the same RPM):\n--> built-1.0-1.cm2.x86_64.rpm (produced by built-1.0-1.cm2.src.rpm, other-1.0-1.cm2.src.rpm)\n")
+}
+
+func TestConflictingRPMProvidersFromSameSRPMInTwoSourceTrees(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+
+	// The same SRPM built from a second source tree packages the same RPM along with its subpackage.
+	forkRun, forkBuild := addTestPackage(t, g, "built-devel")
+	for _, node := range []*pkggraph.PkgNode{forkRun, forkBuild} {
+		node.SrpmPath = "/mariner-fork/out/SRPMS/built-1.0-1.cm2.src.rpm"
+		node.RpmPath = "/mariner-fork/out/RPMS/x86_64/built-devel-1.0-1.cm2.x86_64.rpm"
+	}
+	buildState.RecordBuildResult(&BuildResult{
+		Node:           forkBuild,
+		AncillaryNodes: []*pkggraph.PkgNode{forkBuild},
+		Attempts:       1,
+		BuiltFiles:     []string{forkBuild.RpmPath, "/mariner-fork/out/RPMS/x86_64/built-1.0-1.cm2.x86_64.rpm"},
+	})
+
+	// By base name the two SRPMs can't be told apart.
+	assert.Empty(t, conflictingRPMProviders(g, buildState, (*pkggraph.PkgNode).SRPMFileName, summaryFilter{}.includes))
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{FullSRPMPaths: true})
+	assert.Contains(t, output.String(), "--> built-1.0-1.cm2.x86_64.rpm (produced by /mariner-fork/out/SRPMS/built-1.0-1.cm2.src.rpm, /mariner/out/SRPMS/built-1.0-1.cm2.src.rpm)\n")
+}
+
+func TestBuildSummaryListsForcedRebuilds(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+	assert.NotContains(t, output.String(), "Forced rebuilds")
+
+	res := buildState.NodeBuildResult(buildNodes["built"])
+	res.CacheAvailable = true
+	res.CacheMissReason = CacheMissRebuildRequested
+	res.Duration = 90 * time.Second
+	assert.True(t, buildState.WasNodeForcedRebuild(buildNodes["built"]))
+
+	// A node restored from the cache was not rebuilt, even if its cache entry was available.
+	buildState.NodeBuildResult(buildNodes["cached"]).CacheAvailable = true
+	assert.False(t, buildState.WasNodeForcedRebuild(buildNodes["cached"]))
+
+	output.Reset()
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+	assert.Contains(t, output.String(), "Forced rebuilds (i.e., built despite a valid cache entry, 1m30s total):\n--> built-1.0-1.cm2.src.rpm (1m30s, reason: Rebuild requested)\n")
+}
+
+func TestBuildSummaryReportsDeltaSkippedSRPMs(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+	assert.Contains(t, output.String(), "Number of prebuilt delta SRPMs:    1 (12%)\n--> delta RPMs skipped (rebuilt):  0\n")
+	assert.NotContains(t, output.String(), "Delta-skipped SRPMs")
+
+	// A delta node which was built discarded its delta RPMs.
+	recordTestResult(buildState, buildNodes["built"], false, true, nil)
+
+	categories := CategorizeBuildNodes(g, buildState)
+	status := buildStatusFromCategories(categories, buildState, false, false)
+	assert.Equal(t, 1, status.DeltaSkippedCount)
+	assert.Equal(t, 1, status.PrebuiltDeltaCount)
+
+	output.Reset()
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+	assert.Contains(t, output.String(), "--> delta RPMs skipped (rebuilt):  1\n")
+	assert.Contains(t, output.String(), "Delta-skipped SRPMs (i.e., delta mode is on, but the SRPMs were built instead of using delta RPMs):\n--> built-1.0-1.cm2.src.rpm\n")
+}
+
+func TestBuildSummaryWarnsWhenExceedingTimeBudget(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+	buildState.NodeBuildResult(buildNodes["built"]).Duration = 90 * time.Second
+
+	options := SummaryOptions{
+		BuildStartTime:   time.Now().Add(-time.Hour),
+		ExpectedDuration: 2 * time.Hour,
+	}
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, options)
+	assert.NotContains(t, output.String(), "time budget")
+
+	options.ExpectedDuration = 30 * time.Minute
+	output.Reset()
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, options)
+	assert.Contains(t, output.String(), "Build exceeded its time budget of 30m0s by 30m0s, longest builds on the critical path:\n--> built-1.0-1.cm2.src.rpm (1m30s)\n")
+}
+
+func TestSummaryFilesAreWrittenToStdoutForDash(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+
+	var stdout bytes.Buffer
+	previousStdout := summaryStdout
+	summaryStdout = &stdout
+	t.Cleanup(func() { summaryStdout = previousStdout })
+
+	// Run from an empty directory, so a file named "-" would be noticed.
+	workDir := t.TempDir()
+	previousDir, err := os.Getwd()
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chdir(workDir))
+	t.Cleanup(func() { os.Chdir(previousDir) })
+
+	RecordBuildSummary(g, &sync.RWMutex{}, buildState, "-", SummaryCSVOptions{Columns: []string{"Package", "State"}, AppendToExisting: true})
+	assert.True(t, strings.HasPrefix(stdout.String(), "Package,State\n"))
+	assert.Contains(t, stdout.String(), "\nbuilt-1.0-1.cm2.src.rpm,Built\n")
+
+	stdout.Reset()
+	RecordBuildSummaryJSON(g, &sync.RWMutex{}, buildState, "-")
+	assert.Contains(t, stdout.String(), "\"counts\"")
+
+	stdout.Reset()
+	RecordBuildSummaryMarkdown(g, &sync.RWMutex{}, buildState, "-")
+	assert.True(t, strings.HasPrefix(stdout.String(), "## Build summary\n"))
+
+	stdout.Reset()
+	RecordBuildSummaryPrometheus(g, &sync.RWMutex{}, buildState, "-")
+	assert.Contains(t, stdout.String(), "mariner_build_packages_total{state=\"built\"} 1\n")
+
+	stdout.Reset()
+	assert.NoError(t, SaveGraphBuildState(buildState, "-"))
+	assert.Contains(t, stdout.String(), "\"results\"")
+
+	stdout.Reset()
+	streamWriter, err := NewResultStreamWriter("-")
+	assert.NoError(t, err)
+	streamWriter.OnBuildResult(buildState.BuildFailures()[0])
+	assert.NoError(t, streamWriter.Close())
+	assert.Contains(t, stdout.String(), "\"state\":\"Failed\"")
+
+	entries, err := os.ReadDir(workDir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestBuildSummaryListsExternalRuntimeDependencies(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+	assert.NotContains(t, output.String(), "External runtime dependencies")
+
+	opensslNode, err := g.AddPkgNode(&pkgjson.PackageVer{Name: "openssl"}, pkggraph.StateCached, pkggraph.TypeRemoteRun, "<NO_SRPM_PATH>", "/mariner/build/rpm_cache/cache/openssl-1.1.1k-1.cm2.x86_64.rpm", "<NO_SPEC_PATH>", "<NO_SOURCE_PATH>", "<NO_ARCHITECTURE>", "mariner-official-base")
+	assert.NoError(t, err)
+	assert.NoError(t, g.AddEdge(buildNodes["built"], opensslNode))
+	zlibNode, err := g.AddPkgNode(&pkgjson.PackageVer{Name: "zlib"}, pkggraph.StateCached, pkggraph.TypeRemoteRun, "<NO_SRPM_PATH>", "<NO_RPM_PATH>", "<NO_SPEC_PATH>", "<NO_SOURCE_PATH>", "<NO_ARCHITECTURE>", "<NO_REPO>")
+	assert.NoError(t, err)
+	assert.NoError(t, g.AddEdge(buildNodes["built"], zlibNode))
+
+	output.Reset()
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+	assert.Contains(t, output.String(), "External runtime dependencies (i.e., consumed from a repo instead of built):\n"+
+		"--> openssl-1.1.1k-1.cm2.x86_64.rpm (from: mariner-official-base)\n"+
+		"--> "+zlibNode.VersionedPkg.String()+"\n")
+	// Unresolved dependencies are not external dependencies.
+	assert.NotContains(t, output.String(), "--> missing:C:''V:'',C2:''V2:'' (")
+}
+
+func TestPrintBuildSummaryToReportsRPMFanOut(t *testing.T) {
+	g := pkggraph.NewPkgGraph()
+	buildState := NewGraphBuildState(nil)
+	_, appBuild := addTestPackage(t, g, "app")
+	_, emptyBuild := addTestPackage(t, g, "empty")
+	buildState.RecordBuildResult(&BuildResult{
+		Node:           appBuild,
+		AncillaryNodes: []*pkggraph.PkgNode{appBuild},
+		Attempts:       1,
+		BuiltFiles:     []string{"/mariner/out/RPMS/x86_64/app-1.0-1.cm2.x86_64.rpm", "/mariner/out/RPMS/x86_64/app-devel-1.0-1.cm2.x86_64.rpm", "/mariner/out/RPMS/x86_64/app-debuginfo-1.0-1.cm2.x86_64.rpm"},
+	})
+	buildState.RecordBuildResult(&BuildResult{Node: emptyBuild, AncillaryNodes: []*pkggraph.PkgNode{emptyBuild}, Attempts: 1})
+
+	status := CalculateBuildStatus(g, &sync.RWMutex{}, buildState, false, false)
+	assert.Equal(t, 3, status.BuiltRPMCount)
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+	assert.Contains(t, output.String(), "Produced 3 RPMs from 2 SRPMs (avg 1.5)\n")
+}
+
+func TestPrintBuildSummaryToReportsUnusedBuiltPackages(t *testing.T) {
+	g := pkggraph.NewPkgGraph()
+	buildState := NewGraphBuildState(nil)
+	libRun, libBuild := addTestPackage(t, g, "lib")
+	_, appBuild := addTestPackage(t, g, "app")
+	_, toolBuild := addTestPackage(t, g, "tool")
+	assert.NoError(t, g.AddEdge(appBuild, libRun))
+	for _, node := range []*pkggraph.PkgNode{libBuild, appBuild, toolBuild} {
+		recordTestResult(buildState, node, false, false, nil)
+	}
+	_, err := g.AddGoalNode("ALL", nil, false)
+	assert.NoError(t, err)
+
+	// "lib" is consumed by "app", the goal node doesn't count as a consumer.
+	assert.Equal(t, []*pkggraph.PkgNode{appBuild, toolBuild}, unusedBuiltNodes(g, map[string]*pkggraph.PkgNode{
+		"lib": libBuild, "app": appBuild, "tool": toolBuild,
+	}, nil))
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{Deliverables: []string{"app-*"}})
+	assert.Contains(t, output.String(), "Potentially unused built packages (i.e., no other SRPM depends on their RPMs):\n--> tool-1.0-1.cm2.src.rpm\n")
+}
+
+func TestPrintBuildSummaryToReportsFirstAndLastBuildStarted(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+	start := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	buildState.NodeBuildResult(buildNodes["built"]).StartTime = start.Add(90 * time.Minute)
+	buildState.NodeBuildResult(buildNodes["failed"]).StartTime = start
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+	assert.Contains(t, output.String(), "First build started: failed-1.0-1.cm2.src.rpm at 10:00:00.000\n"+
+		"Last build started:  built-1.0-1.cm2.src.rpm at 11:30:00.000\n")
+}
+
+func TestRecordBuildSummaryWritesFullSRPMPaths(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+
+	outputPath := filepath.Join(t.TempDir(), "summary.csv")
+	RecordBuildSummary(g, &sync.RWMutex{}, buildState, outputPath, SummaryCSVOptions{
+		Columns:       []string{"Package", "State", "Blocker", "Blocker Chain", "Known Issue"},
+		FullSRPMPaths: true,
+		KnownIssues:   map[string]string{"build failed": "BUG-1"},
+	})
+	contents, err := os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(contents), "\n/mariner/out/SRPMS/failed-1.0-1.cm2.src.rpm,Failed,,,BUG-1\n")
+	assert.Contains(t, string(contents), "\n/mariner/out/SRPMS/blocked2-1.0-1.cm2.src.rpm,Unbuilt,/mariner/out/SRPMS/blocked-1.0-1.cm2.src.rpm-UNBUILT ,"+
+		"/mariner/out/SRPMS/blocked2-1.0-1.cm2.src.rpm -> /mariner/out/SRPMS/blocked-1.0-1.cm2.src.rpm -> /mariner/out/SRPMS/failed-1.0-1.cm2.src.rpm,\n")
+
+	outputDir := t.TempDir()
+	RecordBuildSummaryPerState(g, &sync.RWMutex{}, buildState, outputDir, []string{"Package", "Blocker"}, true, nil)
+	contents, err = os.ReadFile(filepath.Join(outputDir, "blocked.csv"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(contents), "\n/mariner/out/SRPMS/blocked-1.0-1.cm2.src.rpm,/mariner/out/SRPMS/failed-1.0-1.cm2.src.rpm-FAIL \n")
+}
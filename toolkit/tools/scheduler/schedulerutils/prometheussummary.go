@@ -0,0 +1,75 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/file"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/logger"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+)
+
+// RecordBuildSummaryPrometheus stores the build counts in to a file in the Prometheus text exposition format, suitable
+// for the node_exporter textfile collector. It holds the number of packages per state, the number of toolchain
+// conflicts and the time the file was written at. The file is written to a temporary file first and renamed in to
+// place, so the collector never scrapes a partially written file.
+func RecordBuildSummaryPrometheus(pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, buildState *GraphBuildState, outputPath string) {
+	graphMutex.RLock()
+	defer graphMutex.RUnlock()
+
+	categories := CategorizeBuildNodes(pkgGraph, buildState)
+
+	var metrics strings.Builder
+
+	metrics.WriteString("# HELP mariner_build_packages_total Number of packages in each state at the end of the build.\n")
+	metrics.WriteString("# TYPE mariner_build_packages_total gauge\n")
+	stateCounts := []struct {
+		state string
+		count int
+	}{
+		{"built", len(categories.Built)},
+		{"already_available", len(categories.AlreadyAvailable)},
+		{"prebuilt", len(categories.Prebuilt)},
+		{"prebuilt_delta", len(categories.PrebuiltDelta)},
+		{"skipped", len(categories.Skipped)},
+		{"failed", len(categories.Failures)},
+		{"blocked", len(categories.Unbuilt)},
+		{"unresolved", len(categories.Unresolved)},
+	}
+	for _, stateCount := range stateCounts {
+		fmt.Fprintf(&metrics, "mariner_build_packages_total{state=%q} %d\n", stateCount.state, stateCount.count)
+	}
+
+	metrics.WriteString("# HELP mariner_build_toolchain_conflicts Number of toolchain packages rebuilt by the build.\n")
+	metrics.WriteString("# TYPE mariner_build_toolchain_conflicts gauge\n")
+	fmt.Fprintf(&metrics, "mariner_build_toolchain_conflicts{type=\"rpm\"} %d\n", len(buildState.ConflictingRPMs()))
+	fmt.Fprintf(&metrics, "mariner_build_toolchain_conflicts{type=\"srpm\"} %d\n", len(buildState.ConflictingSRPMs()))
+
+	metrics.WriteString("# HELP mariner_build_timestamp_seconds Unix time the build summary was written at.\n")
+	metrics.WriteString("# TYPE mariner_build_timestamp_seconds gauge\n")
+	fmt.Fprintf(&metrics, "mariner_build_timestamp_seconds %d\n", time.Now().Unix())
+
+	if outputPath == stdoutOutputPath {
+		err := writeReport(metrics.String(), outputPath)
+		if err != nil {
+			logger.Log.Warnf("Failed to write Prometheus metrics to stdout. Error: %s", err)
+		}
+		return
+	}
+
+	tempPath := outputPath + ".tmp"
+	err := file.Write(metrics.String(), tempPath)
+	if err == nil {
+		err = os.Rename(tempPath, outputPath)
+	}
+	if err != nil {
+		os.Remove(tempPath)
+		logger.Log.Warnf("Failed to write Prometheus metrics file '%s'. Error: %s", outputPath, err)
+	}
+}
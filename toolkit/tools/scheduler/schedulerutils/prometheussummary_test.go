@@ -0,0 +1,29 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordBuildSummaryPrometheus(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+	outputPath := filepath.Join(t.TempDir(), "build.prom")
+
+	RecordBuildSummaryPrometheus(g, &sync.RWMutex{}, buildState, outputPath)
+
+	contents, err := os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	metrics := string(contents)
+	assert.Contains(t, metrics, "# TYPE mariner_build_packages_total gauge\nmariner_build_packages_total{state=\"built\"} 1\n")
+	assert.Contains(t, metrics, "mariner_build_packages_total{state=\"blocked\"} 2\n")
+	assert.Contains(t, metrics, "mariner_build_toolchain_conflicts{type=\"rpm\"} 0\nmariner_build_toolchain_conflicts{type=\"srpm\"} 0\n")
+	assert.Contains(t, metrics, "\nmariner_build_timestamp_seconds ")
+	assert.NoFileExists(t, outputPath+".tmp")
+}
@@ -0,0 +1,49 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"sort"
+	"time"
+)
+
+// longestQueueWaitsToList is the number of SRPMs listed in the summary's longest queue waits section.
+const longestQueueWaitsToList = 10
+
+// queueWait returns how long a build request waited for a free worker, or 0 if the times were not recorded.
+func queueWait(res *BuildResult) time.Duration {
+	if res == nil || res.QueuedTime.IsZero() || res.StartTime.IsZero() {
+		return 0
+	}
+
+	return res.StartTime.Sub(res.QueuedTime)
+}
+
+// longestQueueWaits returns up to maxResults build results of the built and failed SRPMs which waited the longest for
+// a free worker, longest first, and the average wait of all of them. High waits indicate the build needs more workers.
+func longestQueueWaits(categories *BuildNodeCategories, buildState *GraphBuildState, maxResults int) (longest []*BuildResult, average time.Duration) {
+	var totalWait time.Duration
+	for _, res := range timelineResults(categories, buildState) {
+		if res.QueuedTime.IsZero() {
+			continue
+		}
+		longest = append(longest, res)
+		totalWait += queueWait(res)
+	}
+
+	if len(longest) == 0 {
+		return
+	}
+	average = totalWait / time.Duration(len(longest))
+
+	sort.SliceStable(longest, func(i, j int) bool {
+		return queueWait(longest[i]) > queueWait(longest[j])
+	})
+
+	if len(longest) > maxResults {
+		longest = longest[:maxResults]
+	}
+
+	return
+}
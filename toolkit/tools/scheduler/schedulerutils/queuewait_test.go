@@ -0,0 +1,39 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintBuildSummaryToReportsQueueWaits(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+	start := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	built := buildState.NodeBuildResult(buildNodes["built"])
+	built.QueuedTime, built.StartTime, built.EndTime = start, start.Add(10*time.Second), start.Add(time.Minute)
+	failed := buildState.NodeBuildResult(buildNodes["failed"])
+	failed.QueuedTime, failed.StartTime, failed.EndTime = start, start.Add(30*time.Second), start.Add(time.Minute)
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+	assert.Contains(t, output.String(), "Average queue wait: 20s\n"+
+		"Longest 2 queue waits (i.e., waiting for a free worker):\n"+
+		"--> failed-1.0-1.cm2.src.rpm (30s)\n"+
+		"--> built-1.0-1.cm2.src.rpm (10s)\n")
+
+	outputPath := filepath.Join(t.TempDir(), "summary.csv")
+	RecordBuildSummary(g, &sync.RWMutex{}, buildState, outputPath, SummaryCSVOptions{Columns: []string{"Package", "Queue Wait"}})
+	contents, err := os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(contents), "\nbuilt-1.0-1.cm2.src.rpm,10s\n")
+	assert.Contains(t, string(contents), "\ncached-1.0-1.cm2.src.rpm,\n")
+}
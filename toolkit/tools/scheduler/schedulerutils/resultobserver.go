@@ -0,0 +1,39 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"sync"
+)
+
+// ResultObserver is notified of every build result as it is processed by the scheduler,
+// for example to drive a progress display.
+type ResultObserver interface {
+	// OnBuildResult is called once for every processed build result. It is called from the scheduler's
+	// main loop, so it should return quickly.
+	OnBuildResult(res *BuildResult)
+}
+
+var (
+	resultObservers      []ResultObserver
+	resultObserversMutex sync.RWMutex
+)
+
+// RegisterResultObserver adds an observer which will be notified by PrintBuildResult of every build result.
+func RegisterResultObserver(observer ResultObserver) {
+	resultObserversMutex.Lock()
+	defer resultObserversMutex.Unlock()
+
+	resultObservers = append(resultObservers, observer)
+}
+
+// notifyResultObservers passes a build result to all registered observers, in registration order.
+func notifyResultObservers(res *BuildResult) {
+	resultObserversMutex.RLock()
+	defer resultObserversMutex.RUnlock()
+
+	for _, observer := range resultObservers {
+		observer.OnBuildResult(res)
+	}
+}
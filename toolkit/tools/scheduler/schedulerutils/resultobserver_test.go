@@ -0,0 +1,35 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"testing"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingObserver is a ResultObserver which remembers every result it was notified of.
+type recordingObserver struct {
+	results []*BuildResult
+}
+
+func (o *recordingObserver) OnBuildResult(res *BuildResult) {
+	o.results = append(o.results, res)
+}
+
+func TestPrintBuildResultNotifiesObservers(t *testing.T) {
+	previousObservers := resultObservers
+	t.Cleanup(func() { resultObservers = previousObservers })
+
+	g := pkggraph.NewPkgGraph()
+	_, buildNode := addTestPackage(t, g, "built")
+	res := &BuildResult{Node: buildNode}
+
+	observer := &recordingObserver{}
+	RegisterResultObserver(observer)
+	PrintBuildResult(res, false)
+
+	assert.Equal(t, []*BuildResult{res}, observer.results)
+}
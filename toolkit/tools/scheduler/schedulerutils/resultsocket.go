@@ -0,0 +1,138 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/logger"
+)
+
+// resultSocketWriteTimeout bounds how long a slow client can stall the scheduler's main loop for each result.
+const resultSocketWriteTimeout = time.Second
+
+// resultEvent is the JSON line published for every build result by a ResultSocketPublisher and a ResultStreamWriter.
+type resultEvent struct {
+	Node     string  `json:"node"`
+	Package  string  `json:"package,omitempty"`
+	State    string  `json:"state"`
+	LogFile  string  `json:"logFile,omitempty"`
+	Duration float64 `json:"duration"`
+	Attempts int     `json:"attempts"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// ResultSocketPublisher is a ResultObserver which publishes every build result as a JSON line to all clients connected
+// to a Unix domain socket, so a running build can be monitored live. Clients connect at any time and receive the
+// results processed from then on. Clients which disconnect or stop reading are dropped without affecting the build.
+type ResultSocketPublisher struct {
+	listener     net.Listener
+	clients      map[net.Conn]bool
+	clientsMutex sync.Mutex
+}
+
+// NewResultSocketPublisher listens for clients on a Unix domain socket at socketPath, replacing any stale socket left
+// by a previous build. Register the publisher with RegisterResultObserver and Close it once the build is done.
+func NewResultSocketPublisher(socketPath string) (publisher *ResultSocketPublisher, err error) {
+	err = os.Remove(socketPath)
+	if err != nil && !os.IsNotExist(err) {
+		return
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return
+	}
+
+	publisher = &ResultSocketPublisher{
+		listener: listener,
+		clients:  make(map[net.Conn]bool),
+	}
+	go publisher.acceptClients()
+
+	return
+}
+
+// newResultEventLine returns the JSON line describing a build result, including the trailing newline.
+func newResultEventLine(res *BuildResult) (line []byte, err error) {
+	event := resultEvent{
+		Node:     res.Node.FriendlyName(),
+		State:    buildResultState(res),
+		LogFile:  res.LogFile,
+		Duration: res.Duration.Seconds(),
+		Attempts: res.Attempts,
+	}
+	if res.Node.SrpmPath != "" {
+		event.Package = res.Node.SRPMFileName()
+	}
+	if res.Err != nil {
+		event.Error = res.Err.Error()
+	}
+
+	line, err = json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	return append(line, '\n'), nil
+}
+
+// OnBuildResult writes the build result as a JSON line to every connected client.
+func (p *ResultSocketPublisher) OnBuildResult(res *BuildResult) {
+	line, err := newResultEventLine(res)
+	if err != nil {
+		logger.Log.Warnf("Failed to encode build result of '%s' for the results socket. Error: %s", res.Node.FriendlyName(), err)
+		return
+	}
+
+	p.clientsMutex.Lock()
+	defer p.clientsMutex.Unlock()
+
+	for client := range p.clients {
+		client.SetWriteDeadline(time.Now().Add(resultSocketWriteTimeout))
+		_, err = client.Write(line)
+		if err != nil {
+			logger.Log.Debugf("Dropping results socket client. Error: %s", err)
+			client.Close()
+			delete(p.clients, client)
+		}
+	}
+}
+
+// Close stops accepting clients, disconnects the connected ones and removes the socket.
+func (p *ResultSocketPublisher) Close() (err error) {
+	err = p.listener.Close()
+
+	p.clientsMutex.Lock()
+	defer p.clientsMutex.Unlock()
+
+	for client := range p.clients {
+		client.Close()
+		delete(p.clients, client)
+	}
+
+	return
+}
+
+// acceptClients adds every client connecting to the socket until the listener is closed.
+func (p *ResultSocketPublisher) acceptClients() {
+	for {
+		client, err := p.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				logger.Log.Warnf("Stopped accepting results socket clients. Error: %s", err)
+			}
+			return
+		}
+
+		p.clientsMutex.Lock()
+		p.clients[client] = true
+		p.clientsMutex.Unlock()
+	}
+}
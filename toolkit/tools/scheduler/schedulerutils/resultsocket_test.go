@@ -0,0 +1,61 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResultSocketPublisherStreamsResults(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "results.sock")
+	publisher, err := NewResultSocketPublisher(socketPath)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer publisher.Close()
+
+	connectClient := func() net.Conn {
+		client, dialErr := net.Dial("unix", socketPath)
+		assert.NoError(t, dialErr)
+		return client
+	}
+	waitForClients := func(count int) {
+		assert.Eventually(t, func() bool {
+			publisher.clientsMutex.Lock()
+			defer publisher.clientsMutex.Unlock()
+			return len(publisher.clients) == count
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+
+	disconnectedClient := connectClient()
+	client := connectClient()
+	defer client.Close()
+	waitForClients(2)
+	disconnectedClient.Close()
+
+	g := pkggraph.NewPkgGraph()
+	_, builtNode := addTestPackage(t, g, "built")
+	_, failedNode := addTestPackage(t, g, "failed")
+	publisher.OnBuildResult(&BuildResult{Node: builtNode, Duration: 2 * time.Second, Attempts: 1})
+	publisher.OnBuildResult(&BuildResult{Node: failedNode, Err: fmt.Errorf("build failed"), LogFile: "failed.log"})
+
+	reader := bufio.NewReader(client)
+	line, err := reader.ReadString('\n')
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"node": "`+builtNode.FriendlyName()+`", "package": "built-1.0-1.cm2.src.rpm", "state": "Built", "duration": 2, "attempts": 1}`, line)
+	line, err = reader.ReadString('\n')
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"node": "`+failedNode.FriendlyName()+`", "package": "failed-1.0-1.cm2.src.rpm", "state": "Failed", "logFile": "failed.log", "duration": 0, "attempts": 0, "error": "build failed"}`, line)
+
+	// The disconnected client is dropped once writing to it fails.
+	waitForClients(1)
+}
@@ -0,0 +1,57 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"io"
+	"os"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/logger"
+)
+
+// ResultStreamWriter is a ResultObserver which appends every build result to a file as newline-delimited JSON, as the
+// results come in. Each line is written straight to the file, so the results processed before a crash are preserved.
+type ResultStreamWriter struct {
+	output     io.Writer
+	file       *os.File // The file backing output, nil when streaming to stdout
+	outputPath string
+}
+
+// NewResultStreamWriter creates, or truncates, the file at outputPath to stream the build results to.
+// If outputPath is "-", the results are streamed to stdout instead.
+// Register the writer with RegisterResultObserver and Close it once the build is done.
+func NewResultStreamWriter(outputPath string) (writer *ResultStreamWriter, err error) {
+	if outputPath == stdoutOutputPath {
+		writer = &ResultStreamWriter{output: summaryStdout, outputPath: outputPath}
+		return
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return
+	}
+
+	writer = &ResultStreamWriter{output: file, file: file, outputPath: outputPath}
+	return
+}
+
+// OnBuildResult appends the build result to the file as a JSON line.
+func (w *ResultStreamWriter) OnBuildResult(res *BuildResult) {
+	line, err := newResultEventLine(res)
+	if err == nil {
+		_, err = w.output.Write(line)
+	}
+	if err != nil {
+		logger.Log.Warnf("Failed to write build result of '%s' to the results stream '%s'. Error: %s", res.Node.FriendlyName(), w.outputPath, err)
+	}
+}
+
+// Close closes the file, stdout is left open.
+func (w *ResultStreamWriter) Close() (err error) {
+	if w.file == nil {
+		return
+	}
+
+	return w.file.Close()
+}
@@ -0,0 +1,43 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResultStreamWriterWritesNDJSON(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "results.ndjson")
+	writer, err := NewResultStreamWriter(outputPath)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	g := pkggraph.NewPkgGraph()
+	_, builtNode := addTestPackage(t, g, "built")
+	_, failedNode := addTestPackage(t, g, "failed")
+	writer.OnBuildResult(&BuildResult{Node: builtNode, Duration: 2 * time.Second, Attempts: 1})
+
+	// Results are readable before the writer is closed.
+	contents, err := os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"node": "`+builtNode.FriendlyName()+`", "package": "built-1.0-1.cm2.src.rpm", "state": "Built", "duration": 2, "attempts": 1}`, string(contents))
+
+	writer.OnBuildResult(&BuildResult{Node: failedNode, Err: fmt.Errorf("build failed"), LogFile: "failed.log"})
+	assert.NoError(t, writer.Close())
+
+	contents, err = os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(contents), "\n"), "\n")
+	assert.Len(t, lines, 2)
+	assert.JSONEq(t, `{"node": "`+failedNode.FriendlyName()+`", "package": "failed-1.0-1.cm2.src.rpm", "state": "Failed", "logFile": "failed.log", "duration": 0, "attempts": 0, "error": "build failed"}`, lines[1])
+}
@@ -0,0 +1,52 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/logger"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/rpm"
+)
+
+const (
+	// patchLinePrefix and sourceLinePrefix tag the file names listed by sourceCountsQueryFormat.
+	patchLinePrefix  = "patch:"
+	sourceLinePrefix = "source:"
+	// sourceCountsQueryFormat lists every patch and source packaged in an SRPM, one per line.
+	sourceCountsQueryFormat = "[" + patchLinePrefix + "%{PATCH}\n][" + sourceLinePrefix + "%{SOURCE}\n]"
+)
+
+// srpmSourceCounts returns the number of patches and sources packaged in an SRPM, as csv values.
+// Both are empty if the SRPM could not be queried.
+func srpmSourceCounts(srpmPath string) (patches, sources string) {
+	const queryPackageFileArg = "-p"
+
+	results, err := rpm.QueryPackage(srpmPath, sourceCountsQueryFormat, nil, queryPackageFileArg)
+	if err != nil {
+		logger.Log.Debugf("Failed to query the patches and sources of '%s'. Error: %s", srpmPath, err)
+		return
+	}
+
+	patchCount, sourceCount := countSourceLines(results)
+	return strconv.Itoa(patchCount), strconv.Itoa(sourceCount)
+}
+
+// countSourceLines counts the patches and sources listed by a sourceCountsQueryFormat query.
+func countSourceLines(lines []string) (patches, sources int) {
+	for _, line := range lines {
+		switch {
+		// rpm prints "(none)" for tags missing from the header.
+		case strings.HasSuffix(line, "(none)"):
+			continue
+		case strings.HasPrefix(line, patchLinePrefix):
+			patches++
+		case strings.HasPrefix(line, sourceLinePrefix):
+			sources++
+		}
+	}
+
+	return
+}
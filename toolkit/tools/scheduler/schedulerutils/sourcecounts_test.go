@@ -0,0 +1,35 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountSourceLines(t *testing.T) {
+	patches, sources := countSourceLines([]string{"patch:fix-build.patch", "patch:CVE-2023-0001.patch", "source:foo-1.0.tar.gz", "source:foo.signatures.json"})
+	assert.Equal(t, 2, patches)
+	assert.Equal(t, 2, sources)
+
+	patches, sources = countSourceLines([]string{"patch:(none)", "source:foo-1.0.tar.gz"})
+	assert.Equal(t, 0, patches)
+	assert.Equal(t, 1, sources)
+}
+
+func TestRecordBuildSummaryLeavesSourceCountsEmptyForUnreadableSRPMs(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+	outputPath := filepath.Join(t.TempDir(), "summary.csv")
+
+	RecordBuildSummary(g, &sync.RWMutex{}, buildState, outputPath, SummaryCSVOptions{Columns: []string{"Package", "Patches", "Sources"}})
+
+	contents, err := os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(contents), "Package,Patches,Sources\n")
+	assert.Contains(t, string(contents), "\nbuilt-1.0-1.cm2.src.rpm,,\n")
+}
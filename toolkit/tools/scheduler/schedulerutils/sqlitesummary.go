@@ -0,0 +1,66 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/logger"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/shell"
+)
+
+const (
+	// sqliteProgram is the command line tool used to write to SQLite databases.
+	sqliteProgram = "sqlite3"
+	// createBuildsTableStatement creates the table RecordBuildSummarySQLite inserts in to, if it doesn't exist yet.
+	createBuildsTableStatement = "CREATE TABLE IF NOT EXISTS builds (run_id TEXT NOT NULL, package TEXT NOT NULL, state TEXT NOT NULL, duration REAL NOT NULL, blocker TEXT NOT NULL);"
+)
+
+// RecordBuildSummarySQLite inserts one row per SRPM in to the builds table of a SQLite database, creating the database
+// and the table if absent, so trends can be queried across many builds. Rows hold the run ID, the package, its state
+// (using the same names as RecordBuildSummary), the build duration in seconds and the space separated blocking SRPMs.
+// All rows of a build are inserted in a single transaction. Requires the sqlite3 command line tool.
+func RecordBuildSummarySQLite(pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, buildState *GraphBuildState, dbPath, runID string) {
+	graphMutex.RLock()
+	defer graphMutex.RUnlock()
+
+	categories := CategorizeBuildNodes(pkgGraph, buildState)
+
+	statements := []string{"BEGIN TRANSACTION;", createBuildsTableStatement}
+	addPackages := func(nodes map[string]*pkggraph.PkgNode, state string, withBlockers bool) {
+		for _, node := range sortedNodes(nodes) {
+			blockers := ""
+			if withBlockers {
+				blockers = strings.Join(blockingSRPMs(pkgGraph, node, categories), " ")
+			}
+
+			statements = append(statements, fmt.Sprintf("INSERT INTO builds (run_id, package, state, duration, blocker) VALUES (%s, %s, %s, %.3f, %s);",
+				sqliteQuote(runID), sqliteQuote(filepath.Base(node.SrpmPath)), sqliteQuote(state), buildState.NodeBuildDuration(node).Seconds(), sqliteQuote(blockers)))
+		}
+	}
+
+	addPackages(categories.Built, "Built", false)
+	addPackages(categories.AlreadyAvailable, "AlreadyAvailable", false)
+	addPackages(categories.Prebuilt, "PreBuilt", false)
+	addPackages(categories.PrebuiltDelta, "PreBuiltDelta", false)
+	addPackages(categories.Skipped, "Skipped", false)
+	addPackages(categories.Failed, "Failed", true)
+	addPackages(categories.Unbuilt, "Unbuilt", true)
+	statements = append(statements, "COMMIT;")
+
+	// Stop at the first error, so a failed insert rolls back the whole build instead of recording part of it.
+	_, stderr, err := shell.ExecuteWithStdin(strings.Join(statements, "\n"), sqliteProgram, "-bail", dbPath)
+	if err != nil {
+		logger.Log.Warnf("Failed to write to SQLite database '%s'. Error: %s: %s", dbPath, err, strings.TrimSpace(stderr))
+	}
+}
+
+// sqliteQuote returns value as a SQL string literal.
+func sqliteQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
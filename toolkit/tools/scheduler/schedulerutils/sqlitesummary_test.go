@@ -0,0 +1,34 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordBuildSummarySQLite(t *testing.T) {
+	if _, err := exec.LookPath(sqliteProgram); err != nil {
+		t.Skip("sqlite3 is not installed")
+	}
+
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+	buildState.NodeBuildResult(buildNodes["built"]).Duration = 90 * time.Second
+	dbPath := filepath.Join(t.TempDir(), "builds.db")
+
+	RecordBuildSummarySQLite(g, &sync.RWMutex{}, buildState, dbPath, "run-1")
+	RecordBuildSummarySQLite(g, &sync.RWMutex{}, buildState, dbPath, "run-'2'")
+
+	output, err := exec.Command(sqliteProgram, "-list", dbPath, "SELECT run_id, package, state, duration, blocker FROM builds WHERE package IN ('built-1.0-1.cm2.src.rpm', 'blocked-1.0-1.cm2.src.rpm') ORDER BY run_id, package;").Output()
+	assert.NoError(t, err)
+	assert.Equal(t, "run-'2'|blocked-1.0-1.cm2.src.rpm|Unbuilt|0.0|failed-1.0-1.cm2.src.rpm\n"+
+		"run-'2'|built-1.0-1.cm2.src.rpm|Built|90.0|\n"+
+		"run-1|blocked-1.0-1.cm2.src.rpm|Unbuilt|0.0|failed-1.0-1.cm2.src.rpm\n"+
+		"run-1|built-1.0-1.cm2.src.rpm|Built|90.0|\n", string(output))
+}
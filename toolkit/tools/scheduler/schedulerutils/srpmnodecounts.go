@@ -0,0 +1,53 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"sort"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+)
+
+// srpmNodeCount is the number of graph nodes of a single SRPM.
+type srpmNodeCount struct {
+	srpmPath string
+	count    int
+}
+
+// CountNodesPerSRPM returns the number of local build and run nodes of each SRPM in the graph, keyed by SRPM path.
+// Every subpackage and provide of a spec adds a run node, so an unusually high count often points at a spec problem.
+// The caller is responsible for holding the graph's read lock.
+func CountNodesPerSRPM(pkgGraph *pkggraph.PkgGraph) (counts map[string]int) {
+	counts = make(map[string]int)
+	for _, node := range pkgGraph.AllNodes() {
+		if node.Type == pkggraph.TypeLocalBuild || node.Type == pkggraph.TypeLocalRun {
+			counts[node.SrpmPath]++
+		}
+	}
+
+	return
+}
+
+// srpmsWithManyNodes returns the SRPMs with more than threshold graph nodes, most nodes first.
+// No SRPM is returned if threshold is 0 or less.
+func srpmsWithManyNodes(pkgGraph *pkggraph.PkgGraph, threshold int) (outliers []srpmNodeCount) {
+	if threshold <= 0 {
+		return
+	}
+
+	for srpmPath, count := range CountNodesPerSRPM(pkgGraph) {
+		if count > threshold {
+			outliers = append(outliers, srpmNodeCount{srpmPath: srpmPath, count: count})
+		}
+	}
+
+	sort.Slice(outliers, func(i, j int) bool {
+		if outliers[i].count != outliers[j].count {
+			return outliers[i].count > outliers[j].count
+		}
+		return outliers[i].srpmPath < outliers[j].srpmPath
+	})
+
+	return
+}
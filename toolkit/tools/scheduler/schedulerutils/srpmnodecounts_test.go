@@ -0,0 +1,36 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkgjson"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintBuildSummaryToListsSRPMsWithManyNodes(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+	for i := 0; i < 3; i++ {
+		pkgVer := &pkgjson.PackageVer{Name: fmt.Sprintf("built-sub%d", i), Version: "1.0-1.cm2"}
+		_, err := g.AddPkgNode(pkgVer, pkggraph.StateMeta, pkggraph.TypeLocalRun, buildNodes["built"].SrpmPath, "/mariner/out/RPMS/x86_64/"+pkgVer.Name+"-1.0-1.cm2.x86_64.rpm", buildNodes["built"].SpecPath, buildNodes["built"].SourceDir, "x86_64", "<LOCAL>")
+		assert.NoError(t, err)
+	}
+
+	counts := CountNodesPerSRPM(g)
+	assert.Equal(t, 5, counts["/mariner/out/SRPMS/built-1.0-1.cm2.src.rpm"])
+	assert.Equal(t, 2, counts["/mariner/out/SRPMS/failed-1.0-1.cm2.src.rpm"])
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+	assert.NotContains(t, output.String(), "graph nodes")
+
+	output.Reset()
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{MaxNodesPerSRPM: 2})
+	assert.Contains(t, output.String(), "SRPMs with more than 2 graph nodes (i.e., the spec may generate too many subpackages):\n--> built-1.0-1.cm2.src.rpm (5 nodes)\n")
+}
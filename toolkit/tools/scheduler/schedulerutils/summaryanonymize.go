@@ -0,0 +1,60 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// anonymizedNameLength is the number of hex digits of the hash kept by AnonymizePackageName.
+const anonymizedNameLength = 16
+
+// AnonymizePackageName replaces an SRPM name with a hash of it, e.g. "pkg-1a2b3c4d5e6f7a8b". The same name always
+// maps to the same hash, so anonymized summaries of different builds can still be compared. Meant to be used as the
+// PackageLabel of SummaryCSVOptions.
+func AnonymizePackageName(srpm string) string {
+	hash := sha256.Sum256([]byte(srpm))
+	return "pkg-" + hex.EncodeToString(hash[:])[:anonymizedNameLength]
+}
+
+// relabelSummaryPackages renames the SRPMs in the Package, SRPM Path, Blocker and Blocker Chain columns of the rows,
+// keeping the "-FAIL" and "-UNBUILT" suffixes of the blockers and the structure of the chains.
+func relabelSummaryPackages(rows []map[string]string, packageLabel func(srpm string) string) {
+	relabelBlocker := func(blocker string) string {
+		for _, suffix := range []string{"-FAIL", "-UNBUILT"} {
+			if strings.HasSuffix(blocker, suffix) {
+				return packageLabel(strings.TrimSuffix(blocker, suffix)) + suffix
+			}
+		}
+		return packageLabel(blocker)
+	}
+
+	for _, row := range rows {
+		row["Package"] = packageLabel(row["Package"])
+		if row["SRPM Path"] != "" {
+			row["SRPM Path"] = packageLabel(row["SRPM Path"])
+		}
+		row["Blocker"] = relabelFields(row["Blocker"], " ", relabelBlocker)
+
+		chains := strings.Split(row["Blocker Chain"], "; ")
+		for i, chain := range chains {
+			chains[i] = relabelFields(chain, " -> ", packageLabel)
+		}
+		row["Blocker Chain"] = strings.Join(chains, "; ")
+	}
+}
+
+// relabelFields renames each non-empty field of a separator delimited value, keeping empty fields such as a trailing separator.
+func relabelFields(value, separator string, label func(field string) string) string {
+	fields := strings.Split(value, separator)
+	for i, field := range fields {
+		if field != "" {
+			fields[i] = label(field)
+		}
+	}
+
+	return strings.Join(fields, separator)
+}
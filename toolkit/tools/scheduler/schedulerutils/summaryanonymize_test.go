@@ -0,0 +1,32 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordBuildSummaryAnonymizesPackages(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+	outputPath := filepath.Join(t.TempDir(), "summary.csv")
+
+	RecordBuildSummary(g, &sync.RWMutex{}, buildState, outputPath, SummaryCSVOptions{Columns: []string{"Package", "State", "Blocker", "Blocker Chain"}, PackageLabel: AnonymizePackageName})
+
+	contents, err := os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	summary := string(contents)
+	assert.NotContains(t, summary, "failed-1.0-1.cm2.src.rpm")
+	assert.NotContains(t, summary, "blocked-1.0-1.cm2.src.rpm")
+
+	// The same name always maps to the same hash, so the blocker relationships are preserved.
+	failed, blocked := AnonymizePackageName("failed-1.0-1.cm2.src.rpm"), AnonymizePackageName("blocked-1.0-1.cm2.src.rpm")
+	assert.Equal(t, failed, AnonymizePackageName("failed-1.0-1.cm2.src.rpm"))
+	assert.NotEqual(t, failed, blocked)
+	assert.Contains(t, summary, "\n"+blocked+",Unbuilt,"+failed+"-FAIL ,"+blocked+" -> "+failed+"\n")
+}
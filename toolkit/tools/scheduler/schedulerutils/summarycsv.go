@@ -0,0 +1,110 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/sliceutils"
+)
+
+// ParseBuildSummaryCSV reads a csv written by RecordBuildSummary back in to the summary written by
+// RecordBuildSummaryJSON, decompressing it first if csvPath ends with ".gz". The metadata header is skipped.
+// Returns an error if the header is empty, lacks the Package or State column, contains a column which is not one of
+// the SummaryColumns or contains a column twice, or if a row has a State which RecordBuildSummary doesn't write.
+// Summaries written with renamed states can't be parsed.
+// The csv doesn't record unresolved dependencies or conflicts, so their counts are zero. SrpmPath and Blockers are
+// only filled if the SRPM Path and Blocker columns were written, IsDelta is only set for PreBuiltDelta SRPMs.
+func ParseBuildSummaryCSV(csvPath string) (summary *BuildSummaryDocument, err error) {
+	records, err := readCSVRecords(csvPath)
+	if err != nil {
+		err = fmt.Errorf("failed to parse build summary '%s':\n%w", csvPath, err)
+		return
+	}
+
+	if len(records) == 0 || len(records[0]) == 0 {
+		err = fmt.Errorf("build summary '%s' is empty", csvPath)
+		return
+	}
+
+	columns := records[0]
+	seenColumns := make(map[string]bool)
+	for _, column := range columns {
+		if !sliceutils.Contains(SummaryColumns, column, sliceutils.StringMatch) {
+			err = fmt.Errorf("build summary '%s' has an unknown column '%s'", csvPath, column)
+			return
+		}
+		if seenColumns[column] {
+			err = fmt.Errorf("build summary '%s' has the column '%s' twice", csvPath, column)
+			return
+		}
+		seenColumns[column] = true
+	}
+
+	if !seenColumns["Package"] || !seenColumns["State"] {
+		err = fmt.Errorf("build summary '%s' is missing the 'Package' or 'State' column", csvPath)
+		return
+	}
+
+	summary = &BuildSummaryDocument{Packages: make([]PackageSummary, 0)}
+	for i, record := range records[1:] {
+		row := make(map[string]string, len(columns))
+		for j, column := range columns {
+			row[column] = record[j]
+		}
+
+		if !summary.Counts.add(row["State"]) {
+			err = fmt.Errorf("build summary '%s' has an unknown state '%s' in row %d", csvPath, row["State"], i+1)
+			return nil, err
+		}
+
+		summary.Packages = append(summary.Packages, PackageSummary{
+			Package:  row["Package"],
+			State:    row["State"],
+			SrpmPath: row["SRPM Path"],
+			Blockers: parseCSVBlockers(row["Blocker"]),
+			IsDelta:  row["State"] == "PreBuiltDelta",
+		})
+	}
+
+	return
+}
+
+// add counts an SRPM in the given state, using the state names of RecordBuildSummary.
+// Returns false if the state is unknown.
+func (c *BuildSummaryCounts) add(state string) bool {
+	switch state {
+	case "Built":
+		c.Built++
+	case "AlreadyAvailable":
+		c.AlreadyAvailable++
+	case "PreBuilt":
+		c.Prebuilt++
+	case "PreBuiltDelta":
+		c.PrebuiltDelta++
+	case "Skipped":
+		c.Skipped++
+	case "Failed":
+		c.Failed++
+	case "Unbuilt":
+		c.Blocked++
+	default:
+		return false
+	}
+
+	return true
+}
+
+// parseCSVBlockers returns the SRPMs of a Blocker column written by csvBlockers, without their "-FAIL" and "-UNBUILT" suffixes.
+func parseCSVBlockers(column string) (blockers []string) {
+	blockers = make([]string, 0)
+	for _, blocker := range strings.Fields(column) {
+		blocker = strings.TrimSuffix(blocker, "-FAIL")
+		blocker = strings.TrimSuffix(blocker, "-UNBUILT")
+		blockers = append(blockers, blocker)
+	}
+
+	return
+}
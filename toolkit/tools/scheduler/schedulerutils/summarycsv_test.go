@@ -0,0 +1,55 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBuildSummaryCSVRoundTrips(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+	outputPath := filepath.Join(t.TempDir(), "summary.csv.gz")
+	options := SummaryCSVOptions{
+		Columns:  []string{"Package", "State", "Blocker", "SRPM Path"},
+		Metadata: &SummaryMetadata{BuildID: "1234"},
+	}
+	RecordBuildSummary(g, &sync.RWMutex{}, buildState, outputPath, options)
+
+	summary, err := ParseBuildSummaryCSV(outputPath)
+	assert.NoError(t, err)
+
+	// The csv holds the same packages as the JSON summary, but not the unresolved dependencies.
+	expected := newBuildSummaryDocument(g, buildState)
+	expected.Counts.Unresolved = 0
+	sortPackages := func(packages []PackageSummary) {
+		sort.Slice(packages, func(i, j int) bool { return packages[i].Package < packages[j].Package })
+	}
+	sortPackages(expected.Packages)
+	sortPackages(summary.Packages)
+	assert.Equal(t, expected, summary)
+}
+
+func TestParseBuildSummaryCSVValidatesContents(t *testing.T) {
+	testDir := t.TempDir()
+	for name, contents := range map[string]string{
+		"empty":            "",
+		"unknown column":   "Package,State,Color\na.src.rpm,Built,red\n",
+		"duplicate column": "Package,State,Package\na.src.rpm,Built,a.src.rpm\n",
+		"missing state":    "Package,Duration\na.src.rpm,10s\n",
+		"unknown state":    "Package,State\na.src.rpm,Built\nb.src.rpm,SUCCESS\n",
+	} {
+		csvPath := filepath.Join(testDir, strings.ReplaceAll(name, " ", "-")+".csv")
+		assert.NoError(t, os.WriteFile(csvPath, []byte(contents), 0644))
+
+		_, err := ParseBuildSummaryCSV(csvPath)
+		assert.Error(t, err, name)
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+)
+
+// DiffBuildSummaries compares two CSV files written by RecordBuildSummary and writes the differences to w:
+// packages which failed or were blocked in newCSV but not in oldCSV (regressed), packages which are available in
+// newCSV but failed or were blocked in oldCSV (fixed), and packages only found in one of the two files (added and removed).
+// Packages are matched by their SRPM base name, so a version change shows up as one removed and one added package.
+func DiffBuildSummaries(oldCSV, newCSV string, w io.Writer) (err error) {
+	oldStates, err := readSummaryStates(oldCSV)
+	if err != nil {
+		return
+	}
+
+	newStates, err := readSummaryStates(newCSV)
+	if err != nil {
+		return
+	}
+
+	var regressed, fixed, added, removed []string
+	for srpm, newState := range newStates {
+		oldState, found := oldStates[srpm]
+		switch {
+		case !found:
+			added = append(added, srpm)
+		case isFailedSummaryState(newState) && !isFailedSummaryState(oldState):
+			regressed = append(regressed, srpm)
+		case !isFailedSummaryState(newState) && isFailedSummaryState(oldState):
+			fixed = append(fixed, srpm)
+		}
+	}
+
+	for srpm := range oldStates {
+		if _, found := newStates[srpm]; !found {
+			removed = append(removed, srpm)
+		}
+	}
+
+	writeSummaryLine(w, "%d packages regressed, %d fixed, %d added, %d removed", len(regressed), len(fixed), len(added), len(removed))
+
+	writeSection := func(title string, srpms []string, describe func(srpm string) string) {
+		if len(srpms) == 0 {
+			return
+		}
+
+		sort.Strings(srpms)
+		writeSummaryLine(w, "%s:", title)
+		for _, srpm := range srpms {
+			writeSummaryLine(w, "--> %s (%s)", srpm, describe(srpm))
+		}
+	}
+
+	stateChange := func(srpm string) string {
+		return fmt.Sprintf("%s -> %s", oldStates[srpm], newStates[srpm])
+	}
+
+	writeSection("Regressed", regressed, stateChange)
+	writeSection("Fixed", fixed, stateChange)
+	writeSection("Added", added, func(srpm string) string { return newStates[srpm] })
+	writeSection("Removed", removed, func(srpm string) string { return oldStates[srpm] })
+
+	return
+}
+
+// DiffCacheStates compares the GetBuildSummary results of a previous and the current build and writes the SRPMs which
+// changed between being restored from the cache and being built to w, to catch unexpected cache churn between reruns.
+// Returns the base names of the SRPMs cached in previous but built in current, and the ones built in previous but
+// cached in current, sorted. Prebuilt delta SRPMs count as cached. Packages are matched by their SRPM base name.
+func DiffCacheStates(previous, current *BuildNodeCategories, w io.Writer) (cachedToBuilt, builtToCached []string) {
+	previousCached := srpmNameSet(previous.Prebuilt, previous.PrebuiltDelta)
+	currentCached := srpmNameSet(current.Prebuilt, current.PrebuiltDelta)
+	previousBuilt := srpmNameSet(previous.Built)
+	currentBuilt := srpmNameSet(current.Built)
+
+	for srpm := range currentBuilt {
+		if previousCached[srpm] {
+			cachedToBuilt = append(cachedToBuilt, srpm)
+		}
+	}
+	for srpm := range currentCached {
+		if previousBuilt[srpm] {
+			builtToCached = append(builtToCached, srpm)
+		}
+	}
+	sort.Strings(cachedToBuilt)
+	sort.Strings(builtToCached)
+
+	writeSummaryLine(w, "%d packages went from cached to built, %d from built to cached", len(cachedToBuilt), len(builtToCached))
+	writeSection := func(title string, srpms []string) {
+		if len(srpms) == 0 {
+			return
+		}
+
+		writeSummaryLine(w, "%s:", title)
+		for _, srpm := range srpms {
+			writeSummaryLine(w, "--> %s", srpm)
+		}
+	}
+
+	writeSection("Cached before, built now", cachedToBuilt)
+	writeSection("Built before, cached now", builtToCached)
+
+	return
+}
+
+// srpmNameSet returns the set of SRPM base names of the nodes of all of the categories.
+func srpmNameSet(categories ...map[string]*pkggraph.PkgNode) (srpms map[string]bool) {
+	srpms = make(map[string]bool)
+	for _, nodes := range categories {
+		for _, node := range nodes {
+			srpms[node.SRPMFileName()] = true
+		}
+	}
+
+	return
+}
+
+// CheckBuildSummaryRegressions compares the results of a build against a baseline CSV file written by
+// RecordBuildSummary and returns the base names of the SRPMs which were built in the baseline but failed in this build,
+// sorted. Packages are matched by their SRPM base name, packages missing from the baseline are not regressions.
+// err is only set if the baseline can't be read.
+func CheckBuildSummaryRegressions(baselineCSV string, pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, buildState *GraphBuildState) (regressed []string, err error) {
+	baselineStates, err := readSummaryStates(baselineCSV)
+	if err != nil {
+		return
+	}
+
+	categories := GetBuildSummary(pkgGraph, graphMutex, buildState)
+	for srpm := range srpmNameSet(categories.Failed) {
+		if baselineStates[srpm] == "Built" {
+			regressed = append(regressed, srpm)
+		}
+	}
+	sort.Strings(regressed)
+
+	return
+}
+
+// readSummaryStates reads a CSV file written by RecordBuildSummary and maps each SRPM base name to its state.
+// The Package column holds full SRPM paths if the CSV was written with SummaryCSVOptions.FullSRPMPaths, so it is
+// reduced to the base name either way.
+func readSummaryStates(csvPath string) (states map[string]string, err error) {
+	summary, err := ParseBuildSummaryCSV(csvPath)
+	if err != nil {
+		return
+	}
+
+	states = make(map[string]string)
+	for _, pkgSummary := range summary.Packages {
+		states[filepath.Base(pkgSummary.Package)] = pkgSummary.State
+	}
+
+	return
+}
+
+// isFailedSummaryState returns true if a state written by RecordBuildSummary means the SRPM is not available.
+func isFailedSummaryState(state string) bool {
+	return state == "Failed" || state == "Unbuilt"
+}
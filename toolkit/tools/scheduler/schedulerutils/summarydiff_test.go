@@ -0,0 +1,106 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckBuildSummaryRegressions(t *testing.T) {
+	baselineCSV := filepath.Join(t.TempDir(), "baseline.csv")
+	assert.NoError(t, os.WriteFile(baselineCSV, []byte("State,Package\nBuilt,a-1.0-1.cm2.src.rpm\nFailed,b-1.0-1.cm2.src.rpm\nBuilt,c-1.0-1.cm2.src.rpm\nBuilt,d-1.0-1.cm2.src.rpm\n"), 0644))
+
+	g := pkggraph.NewPkgGraph()
+	buildState := NewGraphBuildState(nil)
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		_, buildNode := addTestPackage(t, g, name)
+		var err error
+		if name != "a" && name != "c" {
+			err = fmt.Errorf("build failed")
+		}
+		recordTestResult(buildState, buildNode, false, false, err)
+	}
+
+	// A package which was already failing and a new failing package are not regressions.
+	regressed, err := CheckBuildSummaryRegressions(baselineCSV, g, &sync.RWMutex{}, buildState)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"d-1.0-1.cm2.src.rpm"}, regressed)
+}
+
+func TestCheckBuildSummaryRegressionsWithFullPathBaseline(t *testing.T) {
+	g, _, buildNodes := buildTestSummaryGraph(t)
+	baselineState := NewGraphBuildState(nil)
+	recordTestResult(baselineState, buildNodes["failed"], false, false, nil)
+
+	baselineCSV := filepath.Join(t.TempDir(), "baseline.csv")
+	RecordBuildSummary(g, &sync.RWMutex{}, baselineState, baselineCSV, SummaryCSVOptions{FullSRPMPaths: true})
+	contents, err := os.ReadFile(baselineCSV)
+	assert.NoError(t, err)
+	assert.Contains(t, string(contents), "\n/mariner/out/SRPMS/failed-1.0-1.cm2.src.rpm,Built,\n")
+
+	buildState := NewGraphBuildState(nil)
+	recordTestResult(buildState, buildNodes["failed"], false, false, fmt.Errorf("build failed"))
+
+	regressed, err := CheckBuildSummaryRegressions(baselineCSV, g, &sync.RWMutex{}, buildState)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"failed-1.0-1.cm2.src.rpm"}, regressed)
+}
+
+func TestCheckBuildSummaryRegressionsReportsUnreadableBaseline(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+	testDir := t.TempDir()
+	withoutStates := filepath.Join(testDir, "without-states.csv")
+	assert.NoError(t, os.WriteFile(withoutStates, []byte("Package\nfailed-1.0-1.cm2.src.rpm\n"), 0644))
+
+	for _, baselineCSV := range []string{filepath.Join(testDir, "missing.csv"), withoutStates} {
+		regressed, err := CheckBuildSummaryRegressions(baselineCSV, g, &sync.RWMutex{}, buildState)
+		assert.Error(t, err, baselineCSV)
+		assert.Empty(t, regressed, baselineCSV)
+	}
+}
+
+func TestDiffBuildSummariesReportsChanges(t *testing.T) {
+	summaryDir := t.TempDir()
+	oldCSV := filepath.Join(summaryDir, "old.csv")
+	newCSV := filepath.Join(summaryDir, "new.csv")
+	assert.NoError(t, os.WriteFile(oldCSV, []byte("Package,State,Blocker\na.src.rpm,Built,\nb.src.rpm,Failed,\nc.src.rpm,Built,\nd.src.rpm,PreBuilt,\n"), 0644))
+	assert.NoError(t, os.WriteFile(newCSV, []byte("Package,State,Blocker,Architecture\na.src.rpm,Failed,,x86_64\nb.src.rpm,Built,,x86_64\nc.src.rpm,PreBuilt,,x86_64\ne.src.rpm,Unbuilt,a.src.rpm-FAIL ,x86_64\n"), 0644))
+
+	var output bytes.Buffer
+	assert.NoError(t, DiffBuildSummaries(oldCSV, newCSV, &output))
+
+	assert.Equal(t, "1 packages regressed, 1 fixed, 1 added, 1 removed\n"+
+		"Regressed:\n--> a.src.rpm (Built -> Failed)\n"+
+		"Fixed:\n--> b.src.rpm (Failed -> Built)\n"+
+		"Added:\n--> e.src.rpm (Unbuilt)\n"+
+		"Removed:\n--> d.src.rpm (PreBuilt)\n", output.String())
+}
+
+func TestDiffCacheStates(t *testing.T) {
+	g, previousState, buildNodes := buildTestSummaryGraph(t)
+	previous := CategorizeBuildNodes(g, previousState)
+
+	// Rerun where "cached" had to be built, while "built" was restored from the cache.
+	currentState := NewGraphBuildState(nil)
+	recordTestResult(currentState, buildNodes["cached"], false, false, nil)
+	recordTestResult(currentState, buildNodes["built"], true, false, nil)
+	recordTestResult(currentState, buildNodes["delta"], true, true, nil)
+	current := CategorizeBuildNodes(g, currentState)
+
+	var output bytes.Buffer
+	cachedToBuilt, builtToCached := DiffCacheStates(previous, current, &output)
+	assert.Equal(t, []string{"cached-1.0-1.cm2.src.rpm"}, cachedToBuilt)
+	assert.Equal(t, []string{"built-1.0-1.cm2.src.rpm"}, builtToCached)
+	assert.Equal(t, "1 packages went from cached to built, 1 from built to cached\n"+
+		"Cached before, built now:\n--> cached-1.0-1.cm2.src.rpm\n"+
+		"Built before, cached now:\n--> built-1.0-1.cm2.src.rpm\n", output.String())
+}
@@ -0,0 +1,172 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"path/filepath"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/logger"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+)
+
+// filterBuildNodeCategories returns a copy of categories which only contains the SRPMs matching packageFilter.
+// Unresolved dependencies are kept if at least one matching SRPM depends on them.
+// The caller is responsible for holding the graph's read lock.
+func filterBuildNodeCategories(pkgGraph *pkggraph.PkgGraph, categories *BuildNodeCategories, packageFilter []string) (filtered *BuildNodeCategories) {
+	filtered = filterCategoryNodes(categories, func(node *pkggraph.PkgNode) bool {
+		return matchesPackageFilter(node, packageFilter)
+	})
+
+	consumers := unresolvedDependencyConsumers(pkgGraph, (*pkggraph.PkgNode).SRPMFileName)
+	for dependency := range categories.Unresolved {
+		for _, srpm := range consumers[dependency] {
+			if matchesSRPMName(srpm, packageFilter) {
+				filtered.Unresolved[dependency] = true
+				break
+			}
+		}
+	}
+
+	return
+}
+
+// excludeToolchainCategories returns a copy of categories without the SRPMs producing toolchain RPMs, i.e. any of the
+// reserved files of buildState. Unresolved dependencies are kept. The caller is responsible for holding the graph's read lock.
+func excludeToolchainCategories(pkgGraph *pkggraph.PkgGraph, categories *BuildNodeCategories, buildState *GraphBuildState) (filtered *BuildNodeCategories) {
+	toolchainSRPMs := toolchainSRPMPaths(pkgGraph, buildState)
+
+	filtered = filterCategoryNodes(categories, func(node *pkggraph.PkgNode) bool {
+		return !toolchainSRPMs[node.SrpmPath]
+	})
+	for dependency := range categories.Unresolved {
+		filtered.Unresolved[dependency] = true
+	}
+
+	return
+}
+
+// toolchainSRPMPaths returns the set of paths of the SRPMs producing toolchain RPMs, i.e. any of the reserved files of
+// buildState. The caller is responsible for holding the graph's read lock.
+func toolchainSRPMPaths(pkgGraph *pkggraph.PkgGraph, buildState *GraphBuildState) (toolchainSRPMs map[string]bool) {
+	toolchainSRPMs = make(map[string]bool)
+	for _, node := range pkgGraph.AllBuildNodes() {
+		if buildState.isConflictWithToolchain(node.RpmPath) {
+			toolchainSRPMs[node.SrpmPath] = true
+		}
+	}
+
+	return
+}
+
+// summaryFilter selects the SRPMs listed by the sections of the summary which walk the graph instead of the categories,
+// so they list the same SRPMs as filterBuildNodeCategories and excludeToolchainCategories keep in the categories.
+// The zero value includes every SRPM.
+type summaryFilter struct {
+	packageFilter  []string        // SRPM base name glob patterns, every SRPM matches if empty
+	toolchainSRPMs map[string]bool // Paths of the excluded SRPMs producing toolchain RPMs, nil if they are included
+}
+
+// newSummaryFilter returns the filter selected by options.PackageFilter and options.ExcludeToolchain.
+// The caller is responsible for holding the graph's read lock.
+func newSummaryFilter(pkgGraph *pkggraph.PkgGraph, buildState *GraphBuildState, options SummaryOptions) (filter summaryFilter) {
+	filter.packageFilter = options.PackageFilter
+	if options.ExcludeToolchain {
+		filter.toolchainSRPMs = toolchainSRPMPaths(pkgGraph, buildState)
+	}
+
+	return
+}
+
+// isActive returns true if the filter may leave out any SRPM.
+func (f summaryFilter) isActive() bool {
+	return len(f.packageFilter) != 0 || f.toolchainSRPMs != nil
+}
+
+// includesSRPM returns true if the SRPM at srpmPath is listed in the summary.
+func (f summaryFilter) includesSRPM(srpmPath string) bool {
+	if len(f.packageFilter) != 0 && !matchesSRPMName(filepath.Base(srpmPath), f.packageFilter) {
+		return false
+	}
+
+	return !f.toolchainSRPMs[srpmPath]
+}
+
+// includes returns true if the node's SRPM is listed in the summary.
+func (f summaryFilter) includes(node *pkggraph.PkgNode) bool {
+	return f.includesSRPM(node.SrpmPath)
+}
+
+// includesDependentOf returns true if any local node of an included SRPM depends directly on node, or if the filter
+// is not active. Used for the nodes which don't belong to a local SRPM, e.g. the packages consumed from a repo.
+// The caller is responsible for holding the graph's read lock.
+func (f summaryFilter) includesDependentOf(pkgGraph *pkggraph.PkgGraph, node *pkggraph.PkgNode) bool {
+	if !f.isActive() {
+		return true
+	}
+
+	dependents := pkgGraph.To(node.ID())
+	for dependents.Next() {
+		dependent := dependents.Node().(*pkggraph.PkgNode)
+		if (dependent.Type == pkggraph.TypeLocalBuild || dependent.Type == pkggraph.TypeLocalRun) && f.includes(dependent) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// filterCategoryNodes returns a copy of categories which only contains the SRPMs and failures whose node is accepted
+// by keep. Unresolved dependencies are not copied.
+func filterCategoryNodes(categories *BuildNodeCategories, keep func(node *pkggraph.PkgNode) bool) (filtered *BuildNodeCategories) {
+	filterNodes := func(nodes map[string]*pkggraph.PkgNode) (filteredNodes map[string]*pkggraph.PkgNode) {
+		filteredNodes = make(map[string]*pkggraph.PkgNode)
+		for srpmPath, node := range nodes {
+			if keep(node) {
+				filteredNodes[srpmPath] = node
+			}
+		}
+		return
+	}
+
+	filtered = &BuildNodeCategories{
+		Built:            filterNodes(categories.Built),
+		AlreadyAvailable: filterNodes(categories.AlreadyAvailable),
+		Prebuilt:         filterNodes(categories.Prebuilt),
+		PrebuiltDelta:    filterNodes(categories.PrebuiltDelta),
+		Skipped:          filterNodes(categories.Skipped),
+		Failed:           filterNodes(categories.Failed),
+		Unbuilt:          filterNodes(categories.Unbuilt),
+		Unresolved:       make(map[string]bool),
+	}
+
+	for _, failure := range categories.Failures {
+		if keep(failure.Node) {
+			filtered.Failures = append(filtered.Failures, failure)
+		}
+	}
+
+	return
+}
+
+// matchesPackageFilter returns true if the node's SRPM base name matches any of the glob patterns in packageFilter.
+func matchesPackageFilter(node *pkggraph.PkgNode, packageFilter []string) bool {
+	return matchesSRPMName(node.SRPMFileName(), packageFilter)
+}
+
+// matchesSRPMName returns true if an SRPM base name matches any of the glob patterns in packageFilter.
+func matchesSRPMName(srpmName string, packageFilter []string) bool {
+	for _, pattern := range packageFilter {
+		matched, err := filepath.Match(pattern, srpmName)
+		if err != nil {
+			logger.Log.Warnf("Invalid package filter pattern '%s'. Error: %s", pattern, err)
+			continue
+		}
+
+		if matched {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,128 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkgjson"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintBuildSummaryToAppliesPackageFilter(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{PackageFilter: []string{"blocked*", "built-1.0-1.cm2.src.rpm"}})
+
+	summary := output.String()
+	assert.Contains(t, summary, "Number of built SRPMs:             1 (33%)\n")
+	assert.Contains(t, summary, "Number of prebuilt SRPMs:          0 (0%)\n")
+	assert.Contains(t, summary, "Number of failed SRPMs:            0 (0%)\n")
+	assert.Contains(t, summary, "Number of blocked SRPMs:           2 (67%)\n")
+	assert.Contains(t, summary, "Number of unresolved dependencies: 1\n")
+	assert.NotContains(t, summary, "cached-1.0-1.cm2.src.rpm")
+}
+
+func TestBuildSummaryExcludesToolchainPackages(t *testing.T) {
+	g := pkggraph.NewPkgGraph()
+	buildState := NewGraphBuildState([]string{"gcc-1.0-1.cm2.x86_64.rpm"})
+	_, gccBuild := addTestPackage(t, g, "gcc")
+	_, appBuild := addTestPackage(t, g, "app")
+	recordTestResult(buildState, gccBuild, false, false, nil)
+	recordTestResult(buildState, appBuild, false, false, nil)
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, true, SummaryOptions{})
+	assert.Contains(t, output.String(), "Number of built SRPMs:             2 (100%)\n")
+
+	output.Reset()
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, true, SummaryOptions{ExcludeToolchain: true})
+	summary := output.String()
+	assert.Contains(t, summary, "Number of built SRPMs:             1 (100%)\n")
+	assert.Contains(t, summary, "Built SRPMs:\n--> app-1.0-1.cm2.src.rpm")
+	assert.NotContains(t, summary, "--> gcc-1.0-1.cm2.src.rpm (version")
+	// Conflicts are still reported.
+	assert.Contains(t, summary, "Number of toolchain SRPM conflicts: 1\n")
+}
+
+// buildTestFilteredSections returns a graph where the toolchain SRPM "gcc" and the SRPM "app" each show up in the
+// sections of the summary which walk the graph: the critical path, the external dependencies, the conflicting
+// providers, the SRPMs with many nodes and the orphaned run nodes.
+func buildTestFilteredSections(t *testing.T) (g *pkggraph.PkgGraph, buildState *GraphBuildState) {
+	g = pkggraph.NewPkgGraph()
+	buildState = NewGraphBuildState([]string{"gcc-1.0-1.cm2.x86_64.rpm"})
+
+	gccRun, gccBuild := addTestPackage(t, g, "gcc")
+	_, appBuild := addTestPackage(t, g, "app")
+	_, libBuild := addTestPackage(t, g, "lib")
+	assert.NoError(t, g.AddEdge(appBuild, gccRun))
+
+	for _, name := range []string{"openssl", "zlib"} {
+		remoteNode, err := g.AddPkgNode(&pkgjson.PackageVer{Name: name}, pkggraph.StateCached, pkggraph.TypeRemoteRun, "<NO_SRPM_PATH>", "/mariner/build/rpm_cache/cache/"+name+"-1.0-1.cm2.x86_64.rpm", "<NO_SPEC_PATH>", "<NO_SOURCE_PATH>", "<NO_ARCHITECTURE>", "mariner-official-base")
+		assert.NoError(t, err)
+
+		if name == "openssl" {
+			assert.NoError(t, g.AddEdge(gccBuild, remoteNode))
+		} else {
+			assert.NoError(t, g.AddEdge(appBuild, remoteNode))
+		}
+	}
+
+	_, err := g.AddPkgNode(&pkgjson.PackageVer{Name: "gcc-orphan", Version: "1.0-1.cm2"}, pkggraph.StateMeta, pkggraph.TypeLocalRun, gccBuild.SrpmPath, "/mariner/out/RPMS/x86_64/gcc-orphan-1.0-1.cm2.x86_64.rpm", gccBuild.SpecPath, gccBuild.SourceDir, "x86_64", "<LOCAL>")
+	assert.NoError(t, err)
+
+	for _, buildNode := range []*pkggraph.PkgNode{gccBuild, appBuild, libBuild} {
+		recordTestResult(buildState, buildNode, false, false, nil)
+		buildState.NodeBuildResult(buildNode).Duration = 2 * time.Minute
+	}
+	// "gcc" and "lib" both package the same library.
+	for _, buildNode := range []*pkggraph.PkgNode{gccBuild, libBuild} {
+		res := buildState.NodeBuildResult(buildNode)
+		res.BuiltFiles = append(res.BuiltFiles, "/mariner/out/RPMS/x86_64/libshared-1.0-1.cm2.x86_64.rpm")
+	}
+
+	return
+}
+
+func TestFilteredSummaryGraphSectionsListOnlyIncludedSRPMs(t *testing.T) {
+	g, buildState := buildTestFilteredSections(t)
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, true, SummaryOptions{MaxNodesPerSRPM: 1})
+	summary := output.String()
+	assert.Contains(t, summary, "Critical path: gcc-1.0-1.cm2.src.rpm -> app-1.0-1.cm2.src.rpm (total 4m0s)\n")
+	assert.Contains(t, summary, "--> openssl-1.0-1.cm2.x86_64.rpm (from: mariner-official-base)\n--> zlib-1.0-1.cm2.x86_64.rpm (from: mariner-official-base)\n")
+	assert.Contains(t, summary, "--> libshared-1.0-1.cm2.x86_64.rpm (produced by gcc-1.0-1.cm2.src.rpm, lib-1.0-1.cm2.src.rpm)\n")
+	assert.Contains(t, summary, "graph nodes (i.e., the spec may generate too many subpackages):\n--> gcc-1.0-1.cm2.src.rpm (3 nodes)\n")
+	assert.Contains(t, summary, "the graph may be malformed):\n--> gcc-orphan")
+
+	for _, options := range []SummaryOptions{
+		{MaxNodesPerSRPM: 1, PackageFilter: []string{"app*"}},
+		{MaxNodesPerSRPM: 1, ExcludeToolchain: true},
+	} {
+		output.Reset()
+		PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, true, options)
+		summary = output.String()
+		assert.Contains(t, summary, "Critical path: app-1.0-1.cm2.src.rpm (total 4m0s)\n")
+		assert.Contains(t, summary, "External runtime dependencies (i.e., consumed from a repo instead of built):\n--> zlib-1.0-1.cm2.x86_64.rpm (from: mariner-official-base)\n")
+		assert.NotContains(t, summary, "openssl")
+		assert.Contains(t, summary, "graph nodes (i.e., the spec may generate too many subpackages):\n--> app-1.0-1.cm2.src.rpm (2 nodes)\n")
+		assert.NotContains(t, summary, "gcc-1.0-1.cm2.src.rpm (3 nodes)")
+		assert.NotContains(t, summary, "Orphaned run nodes")
+	}
+
+	// A conflict is listed, with all of its providers, as long as one of them is included.
+	output.Reset()
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, true, SummaryOptions{PackageFilter: []string{"app*"}})
+	assert.NotContains(t, output.String(), "Conflicting providers")
+
+	output.Reset()
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, true, SummaryOptions{ExcludeToolchain: true})
+	assert.Contains(t, output.String(), "--> libshared-1.0-1.cm2.x86_64.rpm (produced by gcc-1.0-1.cm2.src.rpm, lib-1.0-1.cm2.src.rpm)\n")
+}
@@ -0,0 +1,131 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+	"golang.org/x/sys/unix"
+)
+
+// summaryColor is an ANSI terminal color used to highlight lines of the build summary.
+type summaryColor string
+
+const (
+	colorGreen  summaryColor = "\x1b[32m" // Built SRPMs
+	colorYellow summaryColor = "\x1b[33m" // Prebuilt and skipped SRPMs
+	colorRed    summaryColor = "\x1b[31m" // Failed and blocked SRPMs
+	colorReset               = "\x1b[0m"
+)
+
+// SummaryVerbosity selects how much of the build summary is printed. The zero value prints the full summary.
+type SummaryVerbosity int
+
+const (
+	SummaryVerbosityFull     SummaryVerbosity = iota // The counts, the failures and every other listing
+	SummaryVerbosityFailures SummaryVerbosity = iota // The counts and the failed, blocked and unresolved listings
+	SummaryVerbosityCounts   SummaryVerbosity = iota // The counts only
+)
+
+// SummaryVerbosityFromLevel converts a verbosity level, from 0 (counts only) to 2 (the full summary), in to a SummaryVerbosity.
+func SummaryVerbosityFromLevel(level int) (verbosity SummaryVerbosity, err error) {
+	switch level {
+	case 0:
+		verbosity = SummaryVerbosityCounts
+	case 1:
+		verbosity = SummaryVerbosityFailures
+	case 2:
+		verbosity = SummaryVerbosityFull
+	default:
+		err = fmt.Errorf("invalid summary verbosity level %d, expected 0, 1 or 2", level)
+	}
+
+	return
+}
+
+// summaryWriters holds the destinations for each kind of line in the build summary.
+type summaryWriters struct {
+	info           io.Writer // Regular summary lines
+	verbose        io.Writer // Detailed lines, nil if they should not be generated at all
+	warnings       io.Writer // Lines which need attention without failing the build
+	conflicts      io.Writer // Toolchain conflicts which are ignored
+	fatalConflicts io.Writer // Toolchain conflicts which fail the build
+	colors         bool      // Highlight lines with terminal colors
+	fullSRPMPaths  bool      // Name the listed SRPMs by their full path instead of their base name
+}
+
+// srpmName returns the name a listed SRPM is printed with: its base name, or its full path if the writers use full paths.
+func (w summaryWriters) srpmName(node *pkggraph.PkgNode) string {
+	return srpmNamer(w.fullSRPMPaths)(node)
+}
+
+// srpmNamer returns the function naming SRPMs in the summaries: by their full path if fullSRPMPaths is set,
+// otherwise by their base name.
+func srpmNamer(fullSRPMPaths bool) func(node *pkggraph.PkgNode) string {
+	if fullSRPMPaths {
+		return func(node *pkggraph.PkgNode) string { return node.SrpmPath }
+	}
+	return (*pkggraph.PkgNode).SRPMFileName
+}
+
+// colorize wraps a summary line format in color if the writers use colors, otherwise it is returned as-is.
+func (w summaryWriters) colorize(color summaryColor, format string) string {
+	if !w.colors {
+		return format
+	}
+	return string(color) + format + colorReset
+}
+
+// forVerbosity returns the writers for a section of the summary which is printed at the section's verbosity and above.
+// If the summary's verbosity is lower, the section's info and verbose lines are dropped. Warnings and toolchain
+// conflicts are always kept, so their severity is preserved.
+func (w summaryWriters) forVerbosity(verbosity, section SummaryVerbosity) summaryWriters {
+	// Lower values are more verbose.
+	if verbosity > section {
+		w.info = io.Discard
+		w.verbose = nil
+	}
+
+	return w
+}
+
+// useSummaryColors returns true if the summary logged to the terminal should be colorized: the NO_COLOR environment
+// variable (see https://no-color.org) must not be set and stderr, where the logger prints, must be a terminal.
+func useSummaryColors() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	_, err := unix.IoctlGetTermios(int(os.Stderr.Fd()), unix.TCGETS)
+	return err == nil
+}
+
+// logWriter is an io.Writer which logs every line written to it using logFunc.
+// Each call to Write is expected to contain complete lines.
+type logWriter struct {
+	logFunc func(args ...interface{})
+}
+
+// newLogWriter returns an io.Writer which logs every line written to it using logFunc, e.g. logger.Log.Info.
+func newLogWriter(logFunc func(args ...interface{})) io.Writer {
+	return &logWriter{logFunc: logFunc}
+}
+
+// Write logs each line in p.
+func (w *logWriter) Write(p []byte) (n int, err error) {
+	for _, line := range strings.Split(strings.TrimSuffix(string(p), "\n"), "\n") {
+		w.logFunc(line)
+	}
+
+	return len(p), nil
+}
+
+// writeSummaryLine writes a single formatted line of the build summary to w.
+func writeSummaryLine(w io.Writer, format string, args ...interface{}) {
+	fmt.Fprintf(w, format+"\n", args...)
+}
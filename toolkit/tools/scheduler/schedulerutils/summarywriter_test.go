@@ -0,0 +1,100 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintBuildSummaryColors(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+
+	var output bytes.Buffer
+	writers := summaryWriters{info: &output, conflicts: &output, fatalConflicts: &output, colors: true}
+	printBuildSummary(writers, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+
+	summary := output.String()
+	assert.Contains(t, summary, "\x1b[32mNumber of built SRPMs:             1 (12%)\x1b[0m\n")
+	assert.Contains(t, summary, "\x1b[32m--> built-1.0-1.cm2.src.rpm (version: 1.0, release: 1.cm2)\x1b[0m\n")
+	assert.Contains(t, summary, "\x1b[33m--> skipped-1.0-1.cm2.src.rpm\x1b[0m\n")
+	assert.Contains(t, summary, "\x1b[31m--> blocked-1.0-1.cm2.src.rpm (blocked by failure)\x1b[0m\n")
+
+	t.Setenv("NO_COLOR", "1")
+	assert.False(t, useSummaryColors())
+}
+
+func TestPrintBuildSummaryToHonorsVerbosity(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{Verbosity: SummaryVerbosityCounts})
+	summary := output.String()
+	assert.Contains(t, summary, "Number of failed SRPMs:            1 (12%)\n")
+	assert.NotContains(t, summary, "Failed SRPMs:\n")
+	assert.NotContains(t, summary, "Built SRPMs:\n")
+
+	output.Reset()
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{Verbosity: SummaryVerbosityFailures})
+	summary = output.String()
+	assert.Contains(t, summary, "Failed SRPMs:\n--> failed-1.0-1.cm2.src.rpm , error: build failed")
+	assert.Contains(t, summary, "--> blocked-1.0-1.cm2.src.rpm (blocked by failure)\n")
+	assert.Contains(t, summary, "Unresolved dependencies:\n")
+	assert.NotContains(t, summary, "Built SRPMs:\n")
+
+	// Toolchain conflicts are printed at every verbosity.
+	g = pkggraph.NewPkgGraph()
+	buildState = NewGraphBuildState([]string{"gcc-1.0-1.cm2.x86_64.rpm"})
+	_, gccBuild := addTestPackage(t, g, "gcc")
+	recordTestResult(buildState, gccBuild, false, false, nil)
+
+	output.Reset()
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{Verbosity: SummaryVerbosityCounts})
+	summary = output.String()
+	assert.Contains(t, summary, "Number of toolchain SRPM conflicts: 1\n")
+	assert.Contains(t, summary, "SRPM conflicts with toolchain: \n--> gcc-1.0-1.cm2.src.rpm")
+}
+
+func TestSummaryVerbosityFromLevel(t *testing.T) {
+	for level, expected := range []SummaryVerbosity{SummaryVerbosityCounts, SummaryVerbosityFailures, SummaryVerbosityFull} {
+		verbosity, err := SummaryVerbosityFromLevel(level)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, verbosity)
+	}
+
+	_, err := SummaryVerbosityFromLevel(3)
+	assert.Error(t, err)
+}
+
+func TestBuildSummaryListsFullSRPMPaths(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+
+	var output bytes.Buffer
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{})
+	assert.Contains(t, output.String(), "Failed SRPMs:\n--> failed-1.0-1.cm2.src.rpm , error: build failed")
+
+	output.Reset()
+	PrintBuildSummaryTo(&output, g, &sync.RWMutex{}, buildState, false, SummaryOptions{FullSRPMPaths: true})
+	summary := output.String()
+	assert.Contains(t, summary, "Failed SRPMs:\n--> /mariner/out/SRPMS/failed-1.0-1.cm2.src.rpm , error: build failed")
+	assert.Contains(t, summary, "--> /mariner/out/SRPMS/blocked-1.0-1.cm2.src.rpm (blocked by failure)\n")
+	assert.Contains(t, summary, "--> /mariner/out/SRPMS/available-1.0-1.cm2.src.rpm\n")
+
+	outputPath := filepath.Join(t.TempDir(), "summary.csv")
+	RecordBuildSummary(g, &sync.RWMutex{}, buildState, outputPath, SummaryCSVOptions{Columns: []string{"Package", "SRPM Path"}})
+	contents, err := os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(contents), "\nfailed-1.0-1.cm2.src.rpm,/mariner/out/SRPMS/failed-1.0-1.cm2.src.rpm\n")
+
+	RecordBuildSummary(g, &sync.RWMutex{}, buildState, outputPath, SummaryCSVOptions{Columns: []string{"Package", "SRPM Path"}, PackageLabel: AnonymizePackageName})
+	contents, err = os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(contents), "failed-1.0-1.cm2.src.rpm")
+}
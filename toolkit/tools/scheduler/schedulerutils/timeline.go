@@ -0,0 +1,67 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/logger"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+)
+
+// timelineTimeFormat is the format of the timestamps in the build timeline, precise enough to spot scheduling gaps.
+const timelineTimeFormat = "15:04:05.000"
+
+// PrintBuildTimeline logs when every SRPM built during this build was queued, started and finished, sorted by start
+// time. Gaps between a package being queued and started while workers were idle point at scheduling problems.
+// The timeline has one line per built or failed SRPM, so it is meant for debugging rather than every build.
+func PrintBuildTimeline(pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, buildState *GraphBuildState) {
+	printBuildTimeline(newLogWriter(logger.Log.Info), pkgGraph, graphMutex, buildState)
+}
+
+// printBuildTimeline writes the build timeline to w.
+func printBuildTimeline(w io.Writer, pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, buildState *GraphBuildState) {
+	results := timelineResults(GetBuildSummary(pkgGraph, graphMutex, buildState), buildState)
+
+	writeSummaryLine(w, "---------------------------")
+	writeSummaryLine(w, "------ Build timeline -----")
+	writeSummaryLine(w, "---------------------------")
+	for _, res := range results {
+		queued := "unknown"
+		waited := ""
+		if !res.QueuedTime.IsZero() {
+			queued = res.QueuedTime.Format(timelineTimeFormat)
+			waited = ", waited " + res.StartTime.Sub(res.QueuedTime).Round(time.Millisecond).String()
+		}
+
+		writeSummaryLine(w, "--> %s: queued %s, started %s, finished %s (%s%s)", res.Node.SRPMFileName(), queued,
+			res.StartTime.Format(timelineTimeFormat), res.EndTime.Format(timelineTimeFormat), buildResultState(res), waited)
+	}
+}
+
+// timelineResults returns the results of the built and failed SRPMs which recorded a start time, sorted by start time.
+func timelineResults(categories *BuildNodeCategories, buildState *GraphBuildState) (results []*BuildResult) {
+	for _, node := range categories.Built {
+		if res := buildState.NodeBuildResult(node); res != nil && !res.StartTime.IsZero() {
+			results = append(results, res)
+		}
+	}
+	for _, failure := range categories.Failures {
+		if !failure.StartTime.IsZero() {
+			results = append(results, failure)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if !results[i].StartTime.Equal(results[j].StartTime) {
+			return results[i].StartTime.Before(results[j].StartTime)
+		}
+		return results[i].Node.SRPMFileName() < results[j].Node.SRPMFileName()
+	})
+
+	return
+}
@@ -0,0 +1,30 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintBuildTimeline(t *testing.T) {
+	g, buildState, buildNodes := buildTestSummaryGraph(t)
+	start := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	built := buildState.NodeBuildResult(buildNodes["built"])
+	built.QueuedTime, built.StartTime, built.EndTime = start.Add(time.Second), start.Add(3*time.Second), start.Add(time.Minute)
+	failed := buildState.NodeBuildResult(buildNodes["failed"])
+	failed.StartTime, failed.EndTime = start, start.Add(2*time.Second)
+
+	var output bytes.Buffer
+	printBuildTimeline(&output, g, &sync.RWMutex{}, buildState)
+	assert.Contains(t, output.String(), "------ Build timeline -----\n---------------------------\n"+
+		"--> failed-1.0-1.cm2.src.rpm: queued unknown, started 10:00:00.000, finished 10:00:02.000 (Failed)\n"+
+		"--> built-1.0-1.cm2.src.rpm: queued 10:00:01.000, started 10:00:03.000, finished 10:01:00.000 (Built, waited 2s)\n")
+	assert.NotContains(t, output.String(), "cached-1.0-1.cm2.src.rpm")
+}
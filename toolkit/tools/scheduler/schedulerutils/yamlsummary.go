@@ -0,0 +1,32 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"sync"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/logger"
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+	"gopkg.in/yaml.v3"
+)
+
+// RecordBuildSummaryYAML stores the summary in to a YAML file.
+// It records the same counts and package states as RecordBuildSummaryJSON, using snake_case field names.
+func RecordBuildSummaryYAML(pkgGraph *pkggraph.PkgGraph, graphMutex *sync.RWMutex, buildState *GraphBuildState, outputPath string) {
+	graphMutex.RLock()
+	defer graphMutex.RUnlock()
+
+	summary := newBuildSummaryDocument(pkgGraph, buildState)
+
+	yamlBlob, err := yaml.Marshal(summary)
+	if err != nil {
+		logger.Log.Warnf("Failed to serialize YAML file '%s'. Error: %s", outputPath, err)
+		return
+	}
+
+	err = writeReport(string(yamlBlob), outputPath)
+	if err != nil {
+		logger.Log.Warnf("Failed to write YAML file '%s'. Error: %s", outputPath, err)
+	}
+}
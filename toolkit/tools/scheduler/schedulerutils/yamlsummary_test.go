@@ -0,0 +1,39 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package schedulerutils
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/microsoft/CBL-Mariner/toolkit/tools/internal/pkggraph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordBuildSummaryYAML(t *testing.T) {
+	g, buildState, _ := buildTestSummaryGraph(t)
+	outputPath := filepath.Join(t.TempDir(), "summary.yaml")
+
+	RecordBuildSummaryYAML(g, &sync.RWMutex{}, buildState, outputPath)
+
+	contents, err := os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	summary := string(contents)
+	assert.Contains(t, summary, "counts:\n    built: 1\n    already_available: 1\n")
+	assert.Contains(t, summary, "    srpm_conflicts: 0\n")
+	assert.Contains(t, summary, "  - package: blocked-1.0-1.cm2.src.rpm\n    state: Unbuilt\n    srpm_path: /mariner/out/SRPMS/blocked-1.0-1.cm2.src.rpm\n    blockers:\n      - failed-1.0-1.cm2.src.rpm\n    is_delta: false\n")
+	assert.Contains(t, summary, "  - package: built-1.0-1.cm2.src.rpm\n    state: Built\n    srpm_path: /mariner/out/SRPMS/built-1.0-1.cm2.src.rpm\n    blockers: []\n")
+}
+
+func TestRecordBuildSummaryYAMLEmptyBuild(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "summary.yaml")
+
+	RecordBuildSummaryYAML(pkggraph.NewPkgGraph(), &sync.RWMutex{}, NewGraphBuildState(nil), outputPath)
+
+	contents, err := os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(contents), "packages: []\n")
+}